@@ -1,15 +1,17 @@
 package consumer
 
 import (
+	"bookings_consumer/kafka"
 	"bookings_consumer/models"
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 	"os"
 
-	"bookings_consumer/kafka"
 
 	"gorm.io/gorm/clause"
 
@@ -17,11 +19,21 @@ import (
 	"gorm.io/gorm"
 )
 
+// decrSeatsScript takes a number of seats from seatsLeft:<eventId>. Events
+// with a seat map, marked by seatMap:<eventId>, only give out seats picked
+// by id, so a plain count would leave seatsFree:<eventId> behind. Events
+// with ticket tiers, marked by tiered:<eventId>, only sell seats of a tier.
 var decrSeatsScript = redis.NewScript(`
 local available = redis.call("GET", KEYS[1])
 if not available then
     return -1
 end
+if redis.call("EXISTS", KEYS[2]) == 1 then
+    return -2
+end
+if redis.call("EXISTS", KEYS[3]) == 1 then
+    return -3
+end
 available = tonumber(available)
 local required = tonumber(ARGV[1])
 if available >= required then
@@ -33,6 +45,43 @@ end
 `)
 
 
+// decrTierSeatsScript takes seats from a ticket tier's pool and the event's
+// overall seatsLeft:<eventId> together, so the two never disagree.
+var decrTierSeatsScript = redis.NewScript(`
+local available = redis.call("GET", KEYS[1])
+local tierAvailable = redis.call("GET", KEYS[2])
+if not available or not tierAvailable then
+    return -1
+end
+local required = tonumber(ARGV[1])
+if tonumber(available) >= required and tonumber(tierAvailable) >= required then
+    redis.call("DECRBY", KEYS[1], required)
+    redis.call("DECRBY", KEYS[2], required)
+    return 1
+else
+    return 0
+end
+`)
+
+// claimSeatsScript takes every requested seat out of seatsFree:<eventId> or none
+// of them, keeping seatsLeft:<eventId> in step with the set.
+var claimSeatsScript = redis.NewScript(`
+local available = redis.call("GET", KEYS[1])
+if not available then
+    return -1
+end
+if tonumber(available) < #ARGV then
+    return 0
+end
+for _, seat in ipairs(ARGV) do
+    if redis.call("SISMEMBER", KEYS[2], seat) == 0 then
+        return 0
+    end
+end
+redis.call("SREM", KEYS[2], unpack(ARGV))
+redis.call("DECRBY", KEYS[1], #ARGV)
+return 1
+`)
 
 var casStateScript = redis.NewScript(`
 local current = redis.call("GET", KEYS[1])
@@ -40,6 +89,7 @@ if current == "cancelled" then
     return "cancelled"
 else
     redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[2])
+    redis.call("PUBLISH", KEYS[2], ARGV[1])
     return current
 end
 `)
@@ -48,7 +98,7 @@ const stateTTL = 5 * time.Minute
 
 
 func compareAndSetState(ctx context.Context, rdb *redis.Client, key string, nextState string) (string, error) {
-    res, err := casStateScript.Run(ctx, rdb, []string{key}, nextState, int(stateTTL)).Result()
+    res, err := casStateScript.Run(ctx, rdb, []string{key, statusChannel(key)}, nextState, int(stateTTL)).Result()
     if err != nil {
         return "", err
     }
@@ -56,33 +106,86 @@ func compareAndSetState(ctx context.Context, rdb *redis.Client, key string, next
 }
 
 
-func processBookingMessage(ctx context.Context, value []byte, deps *models.ProcessorDeps){
+// statusChannel is the pub/sub channel the gateway streams a request's
+// state transitions from.
+func statusChannel(reqKey string) string {
+	return "bookingStatus:" + strings.TrimPrefix(reqKey, "bookingRequest:")
+}
+
+// setState records a request's state and announces it to stream listeners.
+func setState(ctx context.Context, rdb *redis.Client, reqKey, state string, ttl time.Duration) {
+	rdb.Set(ctx, reqKey, state, ttl)
+	rdb.Publish(ctx, statusChannel(reqKey), state)
+}
+
+// processBookingMessage dead-letters messages that do not decode straight
+// away. Redis and DB errors are returned so the reader retries the message,
+// which picks up from whatever state the request reached.
+func processBookingMessage(ctx context.Context, value []byte, deps *models.ProcessorDeps) error {
 	var req models.KafkaEvent
 	if err := json.Unmarshal(value, &req); err != nil {
 		log.Printf("Invalid booking message: %v", err)
-		return
+		return kafka.Permanent(err)
+	}
+
+	if req.Action == "confirm" {
+		return confirmHold(ctx, req, deps)
 	}
 
 	reqKey := "bookingRequest:" + req.RequestID
-	state, _ := deps.RedisReq.Get(ctx, reqKey).Result()
+	state, err := deps.RedisReq.Get(ctx, reqKey).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Redis error loading state of %s: %v", req.RequestID, err)
+		return err
+	}
 
 	if state == "" {
+		// a new request, or one whose state already expired
+		known, err := claimRequestID(deps, req)
+		if errors.Is(err, errRequestIDTaken) {
+			// the state key belongs to the other user's request, so it is left alone
+			log.Printf("Dropping request %s from user %s: %v", req.RequestID, req.UserID, err)
+			return nil
+		}
+		if err != nil {
+			log.Printf("DB error claiming request %s: %v", req.RequestID, err)
+			return err
+		}
+
 		state = "state1"
-		deps.RedisReq.Set(ctx, reqKey, state, stateTTL)
-	} 
+		if known {
+			if prior := priorState(deps, req.RequestID); prior != "" {
+				state = prior
+			}
+		}
+		setState(ctx, deps.RedisReq, reqKey, state, stateTTL)
+	}
 
 	req.State = state
 
+	if len(req.Items) > 0 {
+		return processOrder(ctx, req, deps)
+	}
+
+	normalizeSeatIDs(&req)
+
 	switch req.State {
 
-		case "state1":
-			stateHandlerFunc1(ctx, req, deps)
+		case "state1", "waitlisted":
+			// waitlisted requests come back here once seats are released
+			return stateHandlerFunc1(ctx, req, deps)
 
 		case "state2":
-			stateHandlerFunc2(ctx, req, deps)
+			return stateHandlerFunc2(ctx, req, deps)
 
 		case "state3":
-			stateHandlerFunc3(ctx,req, deps)
+			return stateHandlerFunc3(ctx,req, deps)
+
+		case "payment_pending":
+			return stateHandlerPayment(ctx, req, deps)
+
+		case "payment_failed":
+			log.Printf("Request %s already failed payment", req.RequestID)
 
 		case "failed":
 			log.Printf("Request %s already failed", req.RequestID)
@@ -90,162 +193,376 @@ func processBookingMessage(ctx context.Context, value []byte, deps *models.Proce
 		case "success":
 			log.Printf("Request %s already succeeded", req.RequestID)
 
+		case "held":
+			log.Printf("Request %s is already holding seats", req.RequestID)
+
 		case "cancelled":
 			log.Printf("Request %s is already cancelled", req.RequestID)
 	}
+
+	return nil
 }
 
-func stateHandlerFunc1(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) {
+func stateHandlerFunc1(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
 	reqKey := "bookingRequest:" + req.RequestID
-	seatsKey := "seatsLeft:" + req.EventID
 
 	if isCancelled(ctx, deps.RedisReq, reqKey) {
-		insertBooking(deps.DB, req, deps.RedisPrice, "cancelled")
 		log.Printf("Request %s was cancelled before seat allocation", req.RequestID)
-		return
+		return insertBooking(deps.DB, req, deps.RedisPrice, "cancelled")
 	}
 
+	reason, err := checkOnSale(ctx, deps.RedisPrice, req.EventID)
+	if err != nil {
+		log.Printf("Redis error checking sales for %s: %v", req.RequestID, err)
+		return err
+	}
+	if reason != "" {
+		if err := insertBooking(deps.DB, req, deps.RedisPrice, "failed"); err != nil {
+			return err
+		}
+		setState(ctx, deps.RedisReq, reqKey, "failed", stateTTL)
+		log.Printf("Request %s failed: %s", req.RequestID, reason)
+		return nil
+	}
 
-	result, err := decrSeatsScript.Run(ctx, deps.RedisSeats, []string{seatsKey}, req.Seats).Int()
+	result, err := reserveSeats(ctx, deps.RedisSeats, req)
 	if err != nil {
 		log.Printf("Redis error: %v", err)
-		return
+		return err
+	}
+
+	if result == 0 && req.Waitlist && len(req.SeatIDs) == 0 {
+		if joinWaitlist(ctx, req, deps) {
+			return nil
+		}
 	}
 
 	if result <= 0 {
-		insertBooking(deps.DB, req, deps.RedisPrice, "failed")
-		if result == -1 {
-			log.Printf("Request %s failed: event not found", req.RequestID)
-		} else {
-			log.Printf("Request %s failed: not enough seats", req.RequestID)
+		if err := insertBooking(deps.DB, req, deps.RedisPrice, "failed"); err != nil {
+			return err
+		}
+		log.Printf("Request %s failed: %s", req.RequestID, reserveFailure(result))
+		setState(ctx, deps.RedisReq, reqKey, "failed", stateTTL)
+		return nil
+	}
+
+	if req.PromoCode != "" {
+		if err := redeemPromo(ctx, deps.RedisPrice, req); err != nil {
+			releaseSeats(ctx, deps.RedisSeats, req)
+			if !errors.Is(err, errPromoRejected) {
+				// the request is still in state1, so the retry starts over
+				log.Printf("Redis error redeeming promo code for %s: %v", req.RequestID, err)
+				return err
+			}
+			if err := insertBooking(deps.DB, req, deps.RedisPrice, "failed"); err != nil {
+				return err
+			}
+			setState(ctx, deps.RedisReq, reqKey, "failed", stateTTL)
+			log.Printf("Request %s failed: %v", req.RequestID, err)
+			return nil
 		}
-		deps.RedisReq.Set(ctx, reqKey, "failed",stateTTL)
-		return
 	}
 
 	prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, "state2")
 	if err != nil {
+		// the retry starts over from state1, so it must not find the seats gone
 		log.Printf("CAS error: %v", err)
-		return
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
+		return err
 	}
 	if prev == "cancelled" {
-		insertBooking(deps.DB, req, deps.RedisPrice, "cancelled")
-		deps.RedisSeats.IncrBy(ctx, seatsKey, int64(req.Seats))
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
 		log.Printf("Request %s cancelled before moving to state2", req.RequestID)
-		return
+		return insertBooking(deps.DB, req, deps.RedisPrice, "cancelled")
 	}
 
 	req.State = "state2"
-	stateHandlerFunc2(ctx, req, deps)
+	return stateHandlerFunc2(ctx, req, deps)
 }
 
 
-func stateHandlerFunc2(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) {
+func stateHandlerFunc2(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
 	reqKey := "bookingRequest:" + req.RequestID
-	seatsKey := "seatsLeft:" + req.EventID
 
 	if isCancelled(ctx, deps.RedisReq, reqKey) {
-		deps.RedisSeats.IncrBy(ctx, seatsKey, int64(req.Seats))
-		deps.RedisReq.Set(ctx, reqKey, "cancelled", stateTTL)
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
+		setState(ctx, deps.RedisReq, reqKey, "cancelled", stateTTL)
 		log.Printf("Request %s cancelled during processing, seats reverted", req.RequestID)
-		return
+		return nil
+	}
+
+	// holds are charged when they are confirmed, plain bookings right away
+	status, nextState := "payment_pending", "payment_pending"
+	if req.HoldMinutes > 0 {
+		status, nextState = "held", "state3"
 	}
 
-	if insertBooking(deps.DB, req, deps.RedisPrice, "confirmed") {
-		prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, "state3")
+	// a hold takes its seats for good until it expires, so Mongo is told
+	// straight away; plain bookings wait for payment
+	var outbox []models.OutboxMessage
+	if status == "held" {
+		msg, err := seatsUpdateOutbox(req.RequestID, req, "subtract")
 		if err != nil {
-			log.Printf("CAS error: %v", err)
-			return
-		}
-		if prev == "cancelled" {
-			if err := deps.DB.Model(&models.Booking{}).
-				Where("request_id = ?", req.RequestID).
-				Update("status", "cancelled").Error; err != nil {
-				log.Printf("DB error cancelling booking %s: %v", req.RequestID, err)
-			}
-			
-			deps.RedisSeats.IncrBy(ctx, seatsKey, int64(req.Seats))
-			log.Printf("Request %s cancelled before moving to state3", req.RequestID)
-			return
+			log.Printf("Failed to build seats update for %s: %v", req.RequestID, err)
+			return err
 		}
+		outbox = append(outbox, msg)
+	}
 
-		req.State = "state3"
-		stateHandlerFunc3(ctx, req, deps)
+	if err := insertBooking(deps.DB, req, deps.RedisPrice, status, outbox...); err != nil {
+		return err
+	}
+
+	prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, nextState)
+	if err != nil {
+		log.Printf("CAS error: %v", err)
+		return err
+	}
+	if prev == "cancelled" {
+		if err := cancelBooking(deps, req); err != nil {
+			return err
+		}
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
+		log.Printf("Request %s cancelled before moving to %s", req.RequestID, nextState)
+		return nil
 	}
 
+	req.State = nextState
+	if nextState == "payment_pending" {
+		return stateHandlerPayment(ctx, req, deps)
+	}
+	return stateHandlerFunc3(ctx, req, deps)
 }
 
-func stateHandlerFunc3(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) {
+func stateHandlerFunc3(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
 	reqKey := "bookingRequest:" + req.RequestID
-	seatsKey := "seatsLeft:" + req.EventID
 
 	if isCancelled(ctx, deps.RedisReq, reqKey) {
-		if err := deps.DB.Model(&models.Booking{}).
-			Where("request_id = ?", req.RequestID).
-			Update("status", "cancelled").Error; err != nil {
-			log.Printf("DB error cancelling booking %s: %v", req.RequestID, err)
-			return
-		}
-		deps.RedisSeats.IncrBy(ctx, seatsKey, int64(req.Seats))
-		deps.RedisReq.Set(ctx, reqKey, "cancelled", stateTTL)
+		if err := cancelBooking(deps, req); err != nil {
+			return err
+		}
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
+		setState(ctx, deps.RedisReq, reqKey, "cancelled", stateTTL)
 		log.Printf("Request %s cancelled after DB insert, seats reverted", req.RequestID)
-		return
+		return nil
 	}
 
-	err := publishSeatsUpdate(deps.Producer, req)
-	if err != nil {
-		log.Printf("Kafka error: %v", err)
-		return
+	// the seats update for Mongo was queued in the outbox together with the
+	// booking row and is published by the outbox relay
+
+	if req.HoldMinutes > 0 {
+		req.State = "held"
+		setState(ctx, deps.RedisReq, reqKey, "held", holdDuration(req)+stateTTL)
+		log.Printf("Request %s is holding %d seats for %d minutes", req.RequestID, req.Seats, req.HoldMinutes)
+		return nil
 	}
 
 	req.State = "success"
-	deps.RedisReq.Set(ctx, reqKey, "success", stateTTL)
+	setState(ctx, deps.RedisReq, reqKey, "success", stateTTL)
 	log.Printf("Request %s processed successfully", req.RequestID)
+	return nil
 }
 
 
-func publishSeatsUpdate(producer *kafka.Producer, req models.KafkaEvent) error {
+// seatsUpdateOutbox builds the outbox row that tells the update seats
+// consumer to adjust available_seats in Mongo.
+func seatsUpdateOutbox(key string, req models.KafkaEvent, operation string) (models.OutboxMessage, error) {
 	event := models.KafkaUpdateEvent{
 		EventId:   req.EventID,
+		Tier:      req.Tier,
 		Seats:     req.Seats,
-		Operation: "subtract",
+		Operation: operation,
 	}
 
 	payload, err := json.Marshal(event)
 	if err != nil {
-		return err
+		return models.OutboxMessage{}, err
 	}
 
 	topic, _ := os.LookupEnv("UPDATE_SEATS_REQUESTS")
-	return producer.Publish(topic, []byte(req.RequestID), payload)
+	return models.OutboxMessage{Topic: topic, Key: key, Payload: string(payload)}, nil
+}
+
+// cancelBooking marks the request's booking cancelled. If the booking had
+// already taken its seats in Mongo, giving them back is queued in the same
+// transaction.
+func cancelBooking(deps *models.ProcessorDeps, req models.KafkaEvent) error {
+	err := deps.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Booking{}).
+			Where("request_id = ? AND status IN ?", req.RequestID, []string{"confirmed", "held"}).
+			Update("status", "cancelled")
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return tx.Model(&models.Booking{}).
+				Where("request_id = ?", req.RequestID).
+				Update("status", "cancelled").Error
+		}
+
+		msg, err := seatsUpdateOutbox("cancelled:"+req.RequestID, req, "add")
+		if err != nil {
+			return err
+		}
+		return tx.Create(&msg).Error
+	})
+
+	if err != nil {
+		log.Printf("DB error cancelling booking %s: %v", req.RequestID, err)
+	}
+	return err
+}
+
+// normalizeSeatIDs drops duplicate seat ids and makes Seats match the
+// number of named seats when the request picks specific seats. Events with
+// a seat map have no tiers, so a tier sent along with seat ids is dropped.
+func normalizeSeatIDs(req *models.KafkaEvent) {
+	if len(req.SeatIDs) == 0 {
+		return
+	}
+
+	req.Tier = ""
+
+	seen := make(map[string]bool, len(req.SeatIDs))
+	ids := make([]string, 0, len(req.SeatIDs))
+	for _, id := range req.SeatIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	req.SeatIDs = ids
+	req.Seats = int64(len(ids))
+}
+
+// What reserveSeats returns besides 1, seats taken, and 0, not enough seats.
+const (
+	reserveEventNotFound = -1
+	reserveNeedsSeatIDs  = -2
+	reserveNeedsTier     = -3
+)
+
+// reserveFailure says why reserveSeats did not take the seats.
+func reserveFailure(result int) string {
+	switch result {
+	case reserveEventNotFound:
+		return "event not found"
+	case reserveNeedsSeatIDs:
+		return "event has a seat map, pick seats by seat_ids"
+	case reserveNeedsTier:
+		return "event has ticket tiers, pick one by tier"
+	default:
+		return "not enough seats"
+	}
+}
+
+func reserveSeats(ctx context.Context, rdb *redis.Client, req models.KafkaEvent) (int, error) {
+	seatsKey := "seatsLeft:" + req.EventID
+
+	if req.Tier != "" {
+		return decrTierSeatsScript.Run(ctx, rdb, []string{seatsKey, tierSeatsKey(req.EventID, req.Tier)}, req.Seats).Int()
+	}
+
+	if len(req.SeatIDs) == 0 {
+		return decrSeatsScript.Run(ctx, rdb, []string{seatsKey, "seatMap:" + req.EventID, "tiered:" + req.EventID}, req.Seats).Int()
+	}
+
+	args := make([]interface{}, len(req.SeatIDs))
+	for i, id := range req.SeatIDs {
+		args[i] = id
+	}
+	freeKey := "seatsFree:" + req.EventID
+	return claimSeatsScript.Run(ctx, rdb, []string{seatsKey, freeKey}, args...).Int()
+}
+
+func releaseSeats(ctx context.Context, rdb *redis.Client, req models.KafkaEvent) {
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.IncrBy(ctx, "seatsLeft:"+req.EventID, req.Seats)
+		if req.Tier != "" {
+			pipe.IncrBy(ctx, tierSeatsKey(req.EventID, req.Tier), req.Seats)
+		}
+		if len(req.SeatIDs) > 0 {
+			members := make([]interface{}, len(req.SeatIDs))
+			for i, id := range req.SeatIDs {
+				members[i] = id
+			}
+			pipe.SAdd(ctx, "seatsFree:"+req.EventID, members...)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Redis error releasing seats for %s: %v", req.RequestID, err)
+	}
 }
 
+// tierSeatsKey is the seat counter of one ticket tier, kept next to the
+// event-wide seatsLeft:<eventId> by the events service.
+func tierSeatsKey(eventID, tier string) string {
+	return "seatsLeft:" + eventID + ":" + tier
+}
 
 func isCancelled(ctx context.Context, rdb *redis.Client, key string) bool {
 	state, _ := rdb.Get(ctx, key).Result()
 	return state == "cancelled"
 }
 
-func insertBooking(db *gorm.DB, req models.KafkaEvent, redisPrice *redis.Client, status string) bool {
+// unitPrice is what one seat of the request's event, or tier, costs now.
+func unitPrice(redisPrice *redis.Client, req models.KafkaEvent) float64 {
 	priceKey := "price:" + req.EventID
+	if req.Tier != "" {
+		priceKey += ":" + req.Tier
+	}
 	priceStr, _ := redisPrice.Get(context.Background(), priceKey).Result()
 	price, _ := strconv.ParseFloat(priceStr, 64)
+	return price
+}
+
+// insertBooking records the booking and, only when the row is new, the
+// outbox messages that go with it, all in one transaction. The price is
+// whatever price:<eventId> says right now, which dynamic pricing moves, so
+// the unit price charged is kept on the row.
+func insertBooking(db *gorm.DB, req models.KafkaEvent, redisPrice *redis.Client, status string, outbox ...models.OutboxMessage) error {
+	price := unitPrice(redisPrice, req)
+
+	total := price * float64(req.Seats)
+	promoCode, discount := promoDiscount(context.Background(), redisPrice, req.RequestID, total)
 
 	err := db.Transaction(func(tx *gorm.DB) error {
 		booking := models.Booking{
 			RequestID: req.RequestID,
 			EventID:   req.EventID,
+			Tier:      req.Tier,
 			UserID:    req.UserID,
-			Price:     price * float64(req.Seats),
+			UnitPrice: price,
+			Price:     total - discount,
+			PromoCode: promoCode,
+			Discount:  discount,
 			Seats:     req.Seats,
+			SeatIDs:   strings.Join(req.SeatIDs, ","),
 			Status:    status,
 		}
-		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&booking).Error
+		if status == "held" {
+			expiresAt := time.Now().Add(holdDuration(req))
+			booking.HoldExpiresAt = &expiresAt
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&booking)
+		if res.Error != nil || res.RowsAffected == 0 || len(outbox) == 0 {
+			return res.Error
+		}
+
+		return tx.Create(&outbox).Error
 	})
 
 	if err != nil {
 		log.Printf("DB error: %v", err)
-		return false
 	}
-	return true
+	return err
 }
\ No newline at end of file
@@ -0,0 +1,235 @@
+package consumer
+
+import (
+	"bookings_consumer/models"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// fakeTable is an in-memory table that enforces the unique indexes declared
+// on its gorm model, which is all insertBooking relies on to dedupe.
+type fakeTable struct {
+	unique [][]string
+	rows   []map[string]driver.Value
+}
+
+type fakeStore struct {
+	mu     sync.Mutex
+	tables map[string]*fakeTable
+}
+
+var insertPattern = regexp.MustCompile(`^INSERT INTO "(\w+)" \(([^)]*)\) VALUES (.*?)( ON CONFLICT DO NOTHING)?( RETURNING (.*))?$`)
+
+func newFakeStore(t *testing.T, models ...interface{}) *fakeStore {
+	store := &fakeStore{tables: map[string]*fakeTable{}}
+	for _, model := range models {
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("parse schema: %v", err)
+		}
+		table := &fakeTable{}
+		for _, idx := range s.ParseIndexes() {
+			if idx.Class != "UNIQUE" {
+				continue
+			}
+			var columns []string
+			for _, f := range idx.Fields {
+				columns = append(columns, f.DBName)
+			}
+			table.unique = append(table.unique, columns)
+		}
+		store.tables[s.Table] = table
+	}
+	return store
+}
+
+func (s *fakeStore) count(table string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tables[table].rows)
+}
+
+func (s *fakeStore) insert(query string, args []driver.NamedValue) ([][]driver.Value, []string, error) {
+	m := insertPattern.FindStringSubmatch(query)
+	if m == nil {
+		return nil, nil, fmt.Errorf("fake store: unsupported query %q", query)
+	}
+	table, ok := s.tables[m[1]]
+	if !ok {
+		return nil, nil, fmt.Errorf("fake store: unknown table %q", m[1])
+	}
+	columns := unquote(m[2])
+	returning := unquote(m[6])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out [][]driver.Value
+	for i := 0; i+len(columns) <= len(args); i += len(columns) {
+		row := map[string]driver.Value{}
+		for j, c := range columns {
+			row[c] = args[i+j].Value
+		}
+		if table.conflicts(row) {
+			if m[4] != "" {
+				continue
+			}
+			return nil, nil, errors.New("duplicate key value violates unique constraint")
+		}
+		if _, ok := row["id"]; !ok {
+			row["id"] = int64(len(table.rows) + 1)
+		}
+		table.rows = append(table.rows, row)
+
+		values := make([]driver.Value, len(returning))
+		for j, c := range returning {
+			values[j] = row[c]
+		}
+		out = append(out, values)
+	}
+	return out, returning, nil
+}
+
+func (t *fakeTable) conflicts(row map[string]driver.Value) bool {
+	for _, columns := range t.unique {
+		for _, existing := range t.rows {
+			same := true
+			for _, c := range columns {
+				if fmt.Sprint(existing[c]) != fmt.Sprint(row[c]) {
+					same = false
+					break
+				}
+			}
+			if same {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func unquote(list string) []string {
+	if list == "" {
+		return nil
+	}
+	var out []string
+	for _, c := range strings.Split(list, ",") {
+		out = append(out, strings.Trim(c, `" `))
+	}
+	return out
+}
+
+type fakeConn struct{ store *fakeStore }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake store: prepare not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error             { return nil }
+func (c *fakeConn) Rollback() error           { return nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, columns, err := c.store.insert(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, _, err := c.store.insert(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type fakeConnector struct{ store *fakeStore }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{store: c.store}, nil
+}
+func (c fakeConnector) Driver() driver.Driver { return nil }
+
+func openFakeDB(t *testing.T, store *fakeStore) *gorm.DB {
+	conn := sql.OpenDB(fakeConnector{store: store})
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+	return db
+}
+
+// offlineRedis fails every command at once, which leaves the price and
+// promo lookups at their zero values.
+func offlineRedis() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Dialer: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("redis offline")
+		},
+		MaxRetries: -1,
+	})
+}
+
+func TestInsertBookingRedelivery(t *testing.T) {
+	store := newFakeStore(t, &models.Booking{}, &models.OutboxMessage{})
+	db := openFakeDB(t, store)
+	rdb := offlineRedis()
+	defer rdb.Close()
+
+	req := models.KafkaEvent{RequestID: "req-1", EventID: "event-1", Tier: "vip", UserID: "user-1", Seats: 2}
+	outbox := models.OutboxMessage{Topic: "seats", Key: "req-1", Payload: "{}"}
+
+	for delivery := 1; delivery <= 2; delivery++ {
+		if err := insertBooking(db, req, rdb, "held", outbox); err != nil {
+			t.Fatalf("delivery %d: %v", delivery, err)
+		}
+	}
+	if n := store.count("bookings"); n != 1 {
+		t.Fatalf("bookings after redelivery = %d, want 1", n)
+	}
+	if n := store.count("outbox_messages"); n != 1 {
+		t.Fatalf("outbox rows after redelivery = %d, want 1", n)
+	}
+
+	other := req
+	other.Tier = "standard"
+	if err := insertBooking(db, other, rdb, "confirmed"); err != nil {
+		t.Fatalf("second tier: %v", err)
+	}
+	if n := store.count("bookings"); n != 2 {
+		t.Fatalf("bookings after another tier = %d, want 2", n)
+	}
+}
@@ -14,6 +14,7 @@ import (
 func StartBookingConsumer(
 	broker string,
 	topic string,
+	exchangeTopic string,
 	groupID string,
 	deps *models.ProcessorDeps,
 ) {
@@ -28,14 +29,33 @@ func StartBookingConsumer(
 		cancel()
 	}()
 
+	go StartHoldSweeper(ctx, deps)
+	go StartOutboxRelay(ctx, deps.DB, deps.Producer)
+	go startExchangeConsumer(ctx, broker, exchangeTopic, groupID, deps)
+
 	reader := kafka.NewReader(broker, topic, groupID)
 	defer reader.Close()
+	reader.EnableDeadLetter(deps.Producer, kafka.RetryPolicyFromEnv())
 
 	log.Printf("Kafka consumer started: topic=%s, groupID=%s", topic, groupID)
 
 	// Start consuming with handler
 	reader.Start(ctx, func(key, value []byte) error {
-		processBookingMessage(ctx, value, deps)
-		return nil
+		return processBookingMessage(ctx, value, deps)
+	})
+}
+
+// startExchangeConsumer handles booking exchanges, which come in on their
+// own topic and consumer group but share the booking consumer's seats,
+// payments and outbox.
+func startExchangeConsumer(ctx context.Context, broker, topic, groupID string, deps *models.ProcessorDeps) {
+	reader := kafka.NewReader(broker, topic, groupID+"-exchanges")
+	defer reader.Close()
+	reader.EnableDeadLetter(deps.Producer, kafka.RetryPolicyFromEnv())
+
+	log.Printf("Kafka exchange consumer started: topic=%s, groupID=%s", topic, groupID)
+
+	reader.Start(ctx, func(key, value []byte) error {
+		return processExchangeMessage(ctx, value, deps)
 	})
 }
@@ -0,0 +1,302 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"bookings_consumer/kafka"
+	"bookings_consumer/models"
+	"bookings_consumer/payment"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errExchangeSourceGone = errors.New("booking is no longer confirmed")
+
+// processExchangeMessage moves a confirmed booking to another event as a
+// saga. Seats on the target event are taken first and any price increase
+// is charged; only once the new booking is committed are the old seats
+// given back. A step that fails undoes the ones before it, so the customer
+// always keeps one of the two bookings.
+func processExchangeMessage(ctx context.Context, value []byte, deps *models.ProcessorDeps) error {
+	var req models.KafkaExchangeEvent
+	if err := json.Unmarshal(value, &req); err != nil {
+		log.Printf("Invalid exchange message: %v", err)
+		return kafka.Permanent(err)
+	}
+
+	if req.RequestID == "" || req.BookingID == "" || req.EventID == "" {
+		log.Printf("Exchange message missing request_id, booking_id or event_id")
+		return nil
+	}
+
+	reqKey := "bookingRequest:" + req.RequestID
+	state, err := deps.RedisReq.Get(ctx, reqKey).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Redis error loading state of %s: %v", req.RequestID, err)
+		return err
+	}
+
+	if state == "" {
+		// a new request, or one whose state already expired
+		var done models.Exchange
+		err := deps.DB.Where("request_id = ?", req.RequestID).First(&done).Error
+		if err == nil {
+			log.Printf("Exchange %s was already processed: %s", req.RequestID, done.Status)
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("DB error loading exchange %s: %v", req.RequestID, err)
+			return err
+		}
+
+		_, err = claimRequestID(deps, models.KafkaEvent{RequestID: req.RequestID, UserID: req.UserID})
+		if errors.Is(err, errRequestIDTaken) {
+			log.Printf("Dropping exchange %s from user %s: %v", req.RequestID, req.UserID, err)
+			return nil
+		}
+		if err != nil {
+			log.Printf("DB error claiming request %s: %v", req.RequestID, err)
+			return err
+		}
+
+		state = "state1"
+		setState(ctx, deps.RedisReq, reqKey, state, stateTTL)
+	}
+
+	switch state {
+	case "state1":
+		return exchangeHandlerReserve(ctx, req, deps)
+
+	case "exchange_reserved":
+		return exchangeHandlerCommit(ctx, req, deps)
+
+	default:
+		log.Printf("Exchange %s already in state %s", req.RequestID, state)
+	}
+
+	return nil
+}
+
+// loadExchangeSource returns the booking being exchanged, or why it cannot
+// be.
+func loadExchangeSource(deps *models.ProcessorDeps, req models.KafkaExchangeEvent) (models.Booking, string) {
+	var source models.Booking
+	if err := deps.DB.Where("id = ?", req.BookingID).First(&source).Error; err != nil {
+		return source, "booking not found"
+	}
+
+	switch {
+	case source.UserID != req.UserID:
+		return source, "booking belongs to another user"
+	case source.Status != "confirmed":
+		return source, "only confirmed bookings can be exchanged, booking is " + source.Status
+	case source.EventID == req.EventID:
+		return source, "booking is already for this event"
+	}
+
+	return source, ""
+}
+
+// exchangeTarget is the request for the seats the booking moves to. The
+// whole booking moves, so it asks for as many seats as the booking has.
+func exchangeTarget(req models.KafkaExchangeEvent, source models.Booking) models.KafkaEvent {
+	target := models.KafkaEvent{
+		RequestID: req.RequestID,
+		UserID:    req.UserID,
+		EventID:   req.EventID,
+		Tier:      req.Tier,
+		Seats:     source.Seats,
+		SeatIDs:   req.SeatIDs,
+	}
+	normalizeSeatIDs(&target)
+	return target
+}
+
+// exchangeHandlerReserve takes the target seats. It returns an error only
+// for failures worth retrying; an exchange that cannot go through is
+// recorded as failed instead.
+func exchangeHandlerReserve(ctx context.Context, req models.KafkaExchangeEvent, deps *models.ProcessorDeps) error {
+	source, reason := loadExchangeSource(deps, req)
+	if reason != "" {
+		failExchange(ctx, deps, req, source, "failed", reason)
+		return nil
+	}
+
+	target := exchangeTarget(req, source)
+	if target.Seats != source.Seats {
+		failExchange(ctx, deps, req, source, "failed", fmt.Sprintf("pick exactly %d seats", source.Seats))
+		return nil
+	}
+
+	onSale, err := checkOnSale(ctx, deps.RedisPrice, target.EventID)
+	if err != nil {
+		log.Printf("Redis error checking sales for %s: %v", req.RequestID, err)
+		return err
+	}
+	if onSale != "" {
+		failExchange(ctx, deps, req, source, "failed", "target "+onSale)
+		return nil
+	}
+
+	result, err := reserveSeats(ctx, deps.RedisSeats, target)
+	if err != nil {
+		log.Printf("Redis error: %v", err)
+		return err
+	}
+	if result <= 0 {
+		failExchange(ctx, deps, req, source, "failed", "target event: "+reserveFailure(result))
+		return nil
+	}
+
+	setState(ctx, deps.RedisReq, "bookingRequest:"+req.RequestID, "exchange_reserved", stateTTL)
+	return exchangeHandlerCommit(ctx, req, deps)
+}
+
+// exchangeHandlerCommit settles the price difference and swaps the
+// bookings in one transaction, which also queues the seat updates for both
+// events. The source seats only go back to Redis after that commit.
+func exchangeHandlerCommit(ctx context.Context, req models.KafkaExchangeEvent, deps *models.ProcessorDeps) error {
+	reqKey := "bookingRequest:" + req.RequestID
+
+	source, reason := loadExchangeSource(deps, req)
+	target := exchangeTarget(req, source)
+	if reason != "" {
+		releaseSeats(ctx, deps.RedisSeats, target)
+		failExchange(ctx, deps, req, source, "failed", reason)
+		return nil
+	}
+
+	price := unitPrice(deps.RedisPrice, target)
+	newPrice := price * float64(target.Seats)
+	difference := math.Round((newPrice-source.Price)*100) / 100
+
+	paymentRef := source.PaymentRef
+	if difference > 0 {
+		ref, err := deps.Payments.Charge(ctx, payment.ChargeRequest{
+			RequestID: req.RequestID,
+			UserID:    req.UserID,
+			Amount:    difference,
+		})
+		if err != nil {
+			releaseSeats(ctx, deps.RedisSeats, target)
+			failExchange(ctx, deps, req, source, "payment_failed", err.Error())
+			return nil
+		}
+		paymentRef = ref
+	}
+
+	exchange := models.Exchange{
+		RequestID:       req.RequestID,
+		UserID:          req.UserID,
+		FromBookingID:   source.ID,
+		FromEventID:     source.EventID,
+		ToEventID:       target.EventID,
+		ToTier:          target.Tier,
+		Seats:           target.Seats,
+		OldPrice:        source.Price,
+		NewPrice:        newPrice,
+		PriceDifference: difference,
+		PaymentRef:      paymentRef,
+		Status:          "completed",
+	}
+
+	err := deps.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", source.ID, "confirmed").
+			Update("status", "exchanged")
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errExchangeSourceGone
+		}
+
+		booking := models.Booking{
+			RequestID:  req.RequestID,
+			UserID:     req.UserID,
+			EventID:    target.EventID,
+			Tier:       target.Tier,
+			UnitPrice:  price,
+			Price:      newPrice,
+			Seats:      target.Seats,
+			SeatIDs:    strings.Join(target.SeatIDs, ","),
+			Status:     "confirmed",
+			PaymentRef: paymentRef,
+		}
+		if err := tx.Create(&booking).Error; err != nil {
+			return err
+		}
+
+		exchange.ToBookingID = booking.ID
+		if err := tx.Create(&exchange).Error; err != nil {
+			return err
+		}
+
+		taken, err := seatsUpdateOutbox("exchange:"+req.RequestID+":to", target, "subtract")
+		if err != nil {
+			return err
+		}
+		released, err := seatsUpdateOutbox("exchange:"+req.RequestID+":from", bookingRequest(source), "add")
+		if err != nil {
+			return err
+		}
+		return tx.Create(&[]models.OutboxMessage{taken, released}).Error
+	})
+
+	if errors.Is(err, errExchangeSourceGone) {
+		if difference > 0 {
+			if err := deps.Payments.Refund(ctx, paymentRef, difference); err != nil {
+				log.Printf("Refund error for exchange %s: %v", req.RequestID, err)
+			}
+		}
+		releaseSeats(ctx, deps.RedisSeats, target)
+		failExchange(ctx, deps, req, source, "failed", "booking was cancelled during the exchange")
+		return nil
+	}
+	if err != nil {
+		log.Printf("DB error committing exchange %s: %v", req.RequestID, err)
+		return err
+	}
+
+	if difference < 0 {
+		if err := deps.Payments.Refund(ctx, source.PaymentRef, -difference); err != nil {
+			log.Printf("Refund error for exchange %s: %v", req.RequestID, err)
+		}
+	}
+
+	releaseSeats(ctx, deps.RedisSeats, bookingRequest(source))
+	setState(ctx, deps.RedisReq, reqKey, "success", stateTTL)
+	log.Printf("Exchange %s moved booking %s to event %s, price difference %.2f", req.RequestID, source.ID, target.EventID, difference)
+	return nil
+}
+
+// failExchange records why an exchange did not go through and ends the
+// request in state. The original booking is left as it was.
+func failExchange(ctx context.Context, deps *models.ProcessorDeps, req models.KafkaExchangeEvent, source models.Booking, state, reason string) {
+	exchange := models.Exchange{
+		RequestID:     req.RequestID,
+		UserID:        req.UserID,
+		FromBookingID: req.BookingID,
+		FromEventID:   source.EventID,
+		ToEventID:     req.EventID,
+		ToTier:        req.Tier,
+		Seats:         source.Seats,
+		OldPrice:      source.Price,
+		Status:        state,
+		Reason:        reason,
+	}
+	if err := deps.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&exchange).Error; err != nil {
+		log.Printf("DB error recording failed exchange %s: %v", req.RequestID, err)
+	}
+
+	setState(ctx, deps.RedisReq, "bookingRequest:"+req.RequestID, state, stateTTL)
+	log.Printf("Exchange %s failed: %s", req.RequestID, reason)
+}
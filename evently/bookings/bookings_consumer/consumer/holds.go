@@ -0,0 +1,159 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"bookings_consumer/models"
+	"bookings_consumer/payment"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	holdSweepInterval  = 30 * time.Second
+	holdSweepBatchSize = 100
+)
+
+func holdDuration(req models.KafkaEvent) time.Duration {
+	return time.Duration(req.HoldMinutes) * time.Minute
+}
+
+// confirmHold charges for an unexpired hold owned by the caller and turns it
+// into a confirmed booking. Seats were already taken when the hold was
+// placed, so nothing changes in Redis or Mongo here.
+func confirmHold(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
+	reqKey := "bookingRequest:" + req.RequestID
+
+	var booking models.Booking
+	err := deps.DB.
+		Where("request_id = ? AND user_id = ? AND status = ? AND hold_expires_at > ?",
+			req.RequestID, req.UserID, "held", time.Now()).
+		First(&booking).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Request %s has no active hold to confirm", req.RequestID)
+		return nil
+	}
+	if err != nil {
+		log.Printf("DB error loading hold %s: %v", req.RequestID, err)
+		return err
+	}
+
+	ref, err := deps.Payments.Charge(ctx, payment.ChargeRequest{
+		RequestID: req.RequestID,
+		UserID:    req.UserID,
+		Amount:    booking.Price,
+	})
+	if err != nil {
+		// the hold stays in place until it expires so the customer can retry
+		log.Printf("Payment for hold %s failed: %v", req.RequestID, err)
+		return nil
+	}
+
+	res := deps.DB.Model(&models.Booking{}).
+		Where("id = ? AND status = ?", booking.ID, "held").
+		Updates(map[string]interface{}{"status": "confirmed", "payment_ref": ref, "hold_expires_at": nil})
+
+	if res.Error != nil {
+		log.Printf("DB error confirming hold %s: %v", req.RequestID, res.Error)
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		if err := deps.Payments.Refund(ctx, ref, booking.Price); err != nil {
+			log.Printf("Refund error for hold %s: %v", req.RequestID, err)
+		}
+		log.Printf("Hold %s expired or was cancelled while paying, payment refunded", req.RequestID)
+		return nil
+	}
+
+	setState(ctx, deps.RedisReq, reqKey, "success", stateTTL)
+	log.Printf("Hold %s confirmed", req.RequestID)
+	return nil
+}
+
+// StartHoldSweeper periodically expires holds that were never confirmed
+// and gives their seats back until ctx is cancelled.
+func StartHoldSweeper(ctx context.Context, deps *models.ProcessorDeps) {
+	ticker := time.NewTicker(holdSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Hold sweeper stopped")
+			return
+		case <-ticker.C:
+			sweepExpiredHolds(ctx, deps)
+		}
+	}
+}
+
+func sweepExpiredHolds(ctx context.Context, deps *models.ProcessorDeps) {
+	var expired []models.Booking
+
+	err := deps.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND hold_expires_at <= ?", "held", time.Now()).
+			Limit(holdSweepBatchSize).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+
+		if len(expired) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(expired))
+		for i, b := range expired {
+			ids[i] = b.ID
+		}
+
+		if err := tx.Model(&models.Booking{}).Where("id IN ?", ids).Update("status", "expired").Error; err != nil {
+			return err
+		}
+
+		outbox := make([]models.OutboxMessage, len(expired))
+		for i, b := range expired {
+			msg, err := seatsUpdateOutbox("holdExpired:"+b.RequestID, bookingRequest(b), "add")
+			if err != nil {
+				return err
+			}
+			outbox[i] = msg
+		}
+		return tx.Create(&outbox).Error
+	})
+
+	if err != nil {
+		log.Printf("DB error sweeping expired holds: %v", err)
+		return
+	}
+
+	for _, b := range expired {
+		releaseSeats(ctx, deps.RedisSeats, bookingRequest(b))
+		releasePromo(ctx, deps.RedisPrice, b.RequestID)
+
+		setState(ctx, deps.RedisReq, "bookingRequest:"+b.RequestID, "expired", stateTTL)
+		log.Printf("Hold %s expired, %d seats released", b.RequestID, b.Seats)
+	}
+}
+
+// bookingRequest rebuilds the parts of the original request needed to give
+// a stored booking's seats back.
+func bookingRequest(b models.Booking) models.KafkaEvent {
+	req := models.KafkaEvent{
+		RequestID: b.RequestID,
+		EventID:   b.EventID,
+		Tier:      b.Tier,
+		Seats:     b.Seats,
+		UserID:    b.UserID,
+	}
+	if b.SeatIDs != "" {
+		req.SeatIDs = strings.Split(b.SeatIDs, ",")
+	}
+	return req
+}
@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"errors"
+	"log"
+
+	"bookings_consumer/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// errRequestIDTaken is returned by claimRequestID when another user already
+// booked under the request id.
+var errRequestIDTaken = errors.New("request id belongs to another user")
+
+// claimRequestID records that the user is booking under this request id and
+// reports whether it had been seen before. A request id someone else
+// claimed first is refused with errRequestIDTaken.
+func claimRequestID(deps *models.ProcessorDeps, req models.KafkaEvent) (bool, error) {
+	res := deps.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.IdempotencyKey{UserID: req.UserID, RequestID: req.RequestID})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return false, nil
+	}
+
+	var claim models.IdempotencyKey
+	if err := deps.DB.Where("request_id = ?", req.RequestID).First(&claim).Error; err != nil {
+		return false, err
+	}
+	if claim.UserID != req.UserID {
+		return false, errRequestIDTaken
+	}
+	return true, nil
+}
+
+// priorState rebuilds the state of a request that was processed before its
+// Redis state expired, from the booking it left behind. It returns "" when
+// the earlier attempt never got as far as a booking, so it starts over.
+func priorState(deps *models.ProcessorDeps, requestID string) string {
+	var booking models.Booking
+	if err := deps.DB.Where("request_id = ?", requestID).Order("created_at ASC").First(&booking).Error; err != nil {
+		return ""
+	}
+
+	log.Printf("Request %s was already processed, replaying its %s booking", requestID, booking.Status)
+
+	switch booking.Status {
+	case "confirmed":
+		return "success"
+	default:
+		// held, payment_pending and the terminal statuses share their names
+		// with the request states
+		return booking.Status
+	}
+}
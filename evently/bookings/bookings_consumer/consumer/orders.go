@@ -0,0 +1,332 @@
+package consumer
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"bookings_consumer/models"
+	"bookings_consumer/payment"
+
+	"gorm.io/gorm"
+)
+
+// processOrder runs a multi-event order through the same states as a single
+// booking. Every item gets its own Booking row, all sharing the order's
+// request id, and seats, payment and cancellation cover the whole order.
+func processOrder(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
+	switch req.State {
+	case "state1":
+		return orderHandlerReserve(ctx, req, deps)
+
+	case "state2":
+		return orderHandlerInsert(ctx, req, deps)
+
+	case "payment_pending":
+		return orderHandlerPayment(ctx, req, deps)
+
+	case "state3":
+		setState(ctx, deps.RedisReq, "bookingRequest:"+req.RequestID, "success", stateTTL)
+
+	default:
+		log.Printf("Order %s already in state %s", req.RequestID, req.State)
+	}
+
+	return nil
+}
+
+// orderItemRequests turns each item of an order into the single-event
+// request the seat helpers work with.
+func orderItemRequests(req models.KafkaEvent) []models.KafkaEvent {
+	items := make([]models.KafkaEvent, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.KafkaEvent{
+			RequestID: req.RequestID,
+			UserID:    req.UserID,
+			EventID:   item.EventID,
+			Tier:      item.Tier,
+			Seats:     item.Seats,
+		}
+	}
+	return items
+}
+
+func releaseOrderSeats(ctx context.Context, deps *models.ProcessorDeps, items []models.KafkaEvent) {
+	for _, item := range items {
+		releaseSeats(ctx, deps.RedisSeats, item)
+	}
+}
+
+// orderHandlerReserve takes the seats of every item or of none: as soon as
+// one event is short, the seats already taken for the others go back.
+func orderHandlerReserve(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
+	reqKey := "bookingRequest:" + req.RequestID
+	items := orderItemRequests(req)
+
+	if isCancelled(ctx, deps.RedisReq, reqKey) {
+		log.Printf("Order %s was cancelled before seat allocation", req.RequestID)
+		return insertOrder(deps, req, "cancelled")
+	}
+
+	for _, item := range items {
+		reason, err := checkOnSale(ctx, deps.RedisPrice, item.EventID)
+		if err != nil {
+			log.Printf("Redis error checking sales for %s: %v", req.RequestID, err)
+			return err
+		}
+		if reason != "" {
+			if err := insertOrder(deps, req, "failed"); err != nil {
+				return err
+			}
+			setState(ctx, deps.RedisReq, reqKey, "failed", stateTTL)
+			log.Printf("Order %s failed: event %s: %s", req.RequestID, item.EventID, reason)
+			return nil
+		}
+	}
+
+	for i, item := range items {
+		result, err := reserveSeats(ctx, deps.RedisSeats, item)
+		if err == nil && result > 0 {
+			continue
+		}
+
+		releaseOrderSeats(ctx, deps, items[:i])
+		if err != nil {
+			log.Printf("Redis error: %v", err)
+			return err
+		}
+
+		if err := insertOrder(deps, req, "failed"); err != nil {
+			return err
+		}
+		setState(ctx, deps.RedisReq, reqKey, "failed", stateTTL)
+		log.Printf("Order %s failed: event %s: %s", req.RequestID, item.EventID, reserveFailure(result))
+		return nil
+	}
+
+	prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, "state2")
+	if err != nil {
+		// the retry starts over from state1, so it must not find the seats gone
+		log.Printf("CAS error: %v", err)
+		releaseOrderSeats(ctx, deps, items)
+		return err
+	}
+	if prev == "cancelled" {
+		releaseOrderSeats(ctx, deps, items)
+		log.Printf("Order %s cancelled before moving to state2", req.RequestID)
+		return insertOrder(deps, req, "cancelled")
+	}
+
+	req.State = "state2"
+	return orderHandlerInsert(ctx, req, deps)
+}
+
+func orderHandlerInsert(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
+	reqKey := "bookingRequest:" + req.RequestID
+	items := orderItemRequests(req)
+
+	if isCancelled(ctx, deps.RedisReq, reqKey) {
+		releaseOrderSeats(ctx, deps, items)
+		setState(ctx, deps.RedisReq, reqKey, "cancelled", stateTTL)
+		log.Printf("Order %s cancelled during processing, seats reverted", req.RequestID)
+		return nil
+	}
+
+	if err := insertOrder(deps, req, "payment_pending"); err != nil {
+		return err
+	}
+
+	prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, "payment_pending")
+	if err != nil {
+		log.Printf("CAS error: %v", err)
+		return err
+	}
+	if prev == "cancelled" {
+		setBookingStatus(deps, req.RequestID, "cancelled")
+		releaseOrderSeats(ctx, deps, items)
+		log.Printf("Order %s cancelled before payment", req.RequestID)
+		return nil
+	}
+
+	req.State = "payment_pending"
+	return orderHandlerPayment(ctx, req, deps)
+}
+
+// orderHandlerPayment charges the order's total in one go and confirms all
+// of its bookings together.
+func orderHandlerPayment(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
+	reqKey := "bookingRequest:" + req.RequestID
+	items := orderItemRequests(req)
+
+	if isCancelled(ctx, deps.RedisReq, reqKey) {
+		setBookingStatus(deps, req.RequestID, "cancelled")
+		releaseOrderSeats(ctx, deps, items)
+		setState(ctx, deps.RedisReq, reqKey, "cancelled", stateTTL)
+		log.Printf("Order %s cancelled before payment, seats reverted", req.RequestID)
+		return nil
+	}
+
+	var bookings []models.Booking
+	if err := deps.DB.Where("request_id = ?", req.RequestID).Find(&bookings).Error; err != nil {
+		log.Printf("DB error loading order %s for payment: %v", req.RequestID, err)
+		return err
+	}
+	if len(bookings) == 0 {
+		log.Printf("Order %s has no bookings to pay for", req.RequestID)
+		return nil
+	}
+
+	total := 0.0
+	for _, b := range bookings {
+		total += b.Price
+	}
+
+	ref, err := deps.Payments.Charge(ctx, payment.ChargeRequest{
+		RequestID: req.RequestID,
+		UserID:    req.UserID,
+		Amount:    total,
+	})
+	if err != nil {
+		setBookingStatus(deps, req.RequestID, "payment_failed")
+		releaseOrderSeats(ctx, deps, items)
+		setState(ctx, deps.RedisReq, reqKey, "payment_failed", stateTTL)
+		log.Printf("Order %s payment failed, seats reverted: %v", req.RequestID, err)
+		return nil
+	}
+
+	outbox, err := orderSeatsOutbox(req.RequestID, items, "subtract")
+	if err != nil {
+		log.Printf("Failed to build seats updates for %s: %v", req.RequestID, err)
+		return err
+	}
+
+	confirmed := false
+	err = deps.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Booking{}).
+			Where("request_id = ? AND status = ?", req.RequestID, "payment_pending").
+			Updates(map[string]interface{}{"status": "confirmed", "payment_ref": ref})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		confirmed = true
+		return tx.Create(&outbox).Error
+	})
+	if err != nil {
+		log.Printf("DB error confirming order %s: %v", req.RequestID, err)
+		return err
+	}
+
+	if !confirmed {
+		var confirmedRows int64
+		if err := deps.DB.Model(&models.Booking{}).
+			Where("request_id = ? AND status = ?", req.RequestID, "confirmed").
+			Count(&confirmedRows).Error; err != nil {
+			log.Printf("DB error loading order %s after payment: %v", req.RequestID, err)
+			return err
+		}
+
+		// not confirmed by an earlier delivery, so the order was cancelled
+		// while the charge went through and its seats are already back
+		if confirmedRows == 0 {
+			if err := deps.Payments.Refund(ctx, ref, total); err != nil {
+				log.Printf("Refund error for order %s: %v", req.RequestID, err)
+			}
+			log.Printf("Order %s was cancelled while paying, payment refunded", req.RequestID)
+			return nil
+		}
+	}
+
+	prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, "state3")
+	if err != nil {
+		log.Printf("CAS error: %v", err)
+		return err
+	}
+	if prev == "cancelled" {
+		cancelOrder(deps, req.RequestID, items)
+		releaseOrderSeats(ctx, deps, items)
+		if err := deps.Payments.Refund(ctx, ref, total); err != nil {
+			log.Printf("Refund error for order %s: %v", req.RequestID, err)
+		}
+		log.Printf("Order %s cancelled after payment, refunded and seats reverted", req.RequestID)
+		return nil
+	}
+
+	setState(ctx, deps.RedisReq, reqKey, "success", stateTTL)
+	log.Printf("Order %s processed successfully", req.RequestID)
+	return nil
+}
+
+// insertOrder records one booking per item in a single transaction. Rows
+// already written by an earlier delivery are left as they are.
+func insertOrder(deps *models.ProcessorDeps, req models.KafkaEvent, status string) error {
+	items := orderItemRequests(req)
+
+	err := deps.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.Booking{}).Where("request_id = ?", req.RequestID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		bookings := make([]models.Booking, len(items))
+		for i, item := range items {
+			price := unitPrice(deps.RedisPrice, item)
+			bookings[i] = models.Booking{
+				RequestID: req.RequestID,
+				OrderID:   req.RequestID,
+				EventID:   item.EventID,
+				Tier:      item.Tier,
+				UserID:    req.UserID,
+				UnitPrice: price,
+				Price:     price * float64(item.Seats),
+				Seats:     item.Seats,
+				Status:    status,
+			}
+		}
+		return tx.Create(&bookings).Error
+	})
+
+	if err != nil {
+		log.Printf("DB error inserting order %s: %v", req.RequestID, err)
+	}
+	return err
+}
+
+// cancelOrder marks a confirmed order cancelled and queues every event's
+// seats to go back to Mongo.
+func cancelOrder(deps *models.ProcessorDeps, requestID string, items []models.KafkaEvent) {
+	err := deps.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Booking{}).
+			Where("request_id = ? AND status = ?", requestID, "confirmed").
+			Update("status", "cancelled")
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		outbox, err := orderSeatsOutbox("cancelled:"+requestID, items, "add")
+		if err != nil {
+			return err
+		}
+		return tx.Create(&outbox).Error
+	})
+
+	if err != nil {
+		log.Printf("DB error cancelling order %s: %v", requestID, err)
+	}
+}
+
+// orderSeatsOutbox builds one seats update per item, each under its own key
+// so the update seats consumer does not drop them as duplicates.
+func orderSeatsOutbox(key string, items []models.KafkaEvent, operation string) ([]models.OutboxMessage, error) {
+	outbox := make([]models.OutboxMessage, len(items))
+	for i, item := range items {
+		msg, err := seatsUpdateOutbox(key+":"+strconv.Itoa(i), item, operation)
+		if err != nil {
+			return nil, err
+		}
+		outbox[i] = msg
+	}
+	return outbox, nil
+}
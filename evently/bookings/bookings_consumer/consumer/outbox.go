@@ -0,0 +1,79 @@
+package consumer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"bookings_consumer/kafka"
+	"bookings_consumer/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	outboxPollInterval = time.Second
+	outboxBatchSize    = 100
+	outboxMaxBackoff   = time.Minute
+)
+
+// StartOutboxRelay publishes outbox rows to Kafka until ctx is cancelled.
+// Rows that fail to publish are retried with exponential backoff.
+func StartOutboxRelay(ctx context.Context, db *gorm.DB, producer *kafka.Producer) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			relayOutbox(db, producer)
+		}
+	}
+}
+
+func relayOutbox(db *gorm.DB, producer *kafka.Producer) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var pending []models.OutboxMessage
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("id ASC").
+			Limit(outboxBatchSize).
+			Find(&pending).Error; err != nil {
+			return err
+		}
+
+		for _, msg := range pending {
+			now := time.Now()
+
+			if err := producer.Publish(msg.Topic, []byte(msg.Key), []byte(msg.Payload)); err != nil {
+				backoff := time.Second << msg.Attempts
+				if backoff <= 0 || backoff > outboxMaxBackoff {
+					backoff = outboxMaxBackoff
+				}
+
+				if err := tx.Model(&models.OutboxMessage{}).Where("id = ?", msg.ID).Updates(map[string]interface{}{
+					"attempts":        msg.Attempts + 1,
+					"last_error":      err.Error(),
+					"next_attempt_at": now.Add(backoff),
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Model(&models.OutboxMessage{}).Where("id = ?", msg.ID).Update("published_at", now).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Outbox relay error: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package consumer
+
+import (
+	"context"
+	"log"
+
+	"bookings_consumer/models"
+	"bookings_consumer/payment"
+
+	"gorm.io/gorm"
+)
+
+// stateHandlerPayment charges the customer for a booking that already holds
+// its seats. A declined payment gives the seats back the same way a
+// cancellation during state2 does.
+func stateHandlerPayment(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) error {
+	reqKey := "bookingRequest:" + req.RequestID
+
+	if isCancelled(ctx, deps.RedisReq, reqKey) {
+		setBookingStatus(deps, req.RequestID, "cancelled")
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
+		setState(ctx, deps.RedisReq, reqKey, "cancelled", stateTTL)
+		log.Printf("Request %s cancelled before payment, seats reverted", req.RequestID)
+		return nil
+	}
+
+	var booking models.Booking
+	if err := deps.DB.Where("request_id = ?", req.RequestID).First(&booking).Error; err != nil {
+		log.Printf("DB error loading booking %s for payment: %v", req.RequestID, err)
+		return err
+	}
+
+	ref, err := deps.Payments.Charge(ctx, payment.ChargeRequest{
+		RequestID: req.RequestID,
+		UserID:    req.UserID,
+		Amount:    booking.Price,
+	})
+	if err != nil {
+		setBookingStatus(deps, req.RequestID, "payment_failed")
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
+		setState(ctx, deps.RedisReq, reqKey, "payment_failed", stateTTL)
+		log.Printf("Request %s payment failed, seats reverted: %v", req.RequestID, err)
+		return nil
+	}
+
+	outbox, err := seatsUpdateOutbox(req.RequestID, req, "subtract")
+	if err != nil {
+		log.Printf("Failed to build seats update for %s: %v", req.RequestID, err)
+		return err
+	}
+
+	confirmed := false
+	err = deps.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Booking{}).
+			Where("request_id = ? AND status = ?", req.RequestID, "payment_pending").
+			Updates(map[string]interface{}{"status": "confirmed", "payment_ref": ref})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		confirmed = true
+		return tx.Create(&outbox).Error
+	})
+	if err != nil {
+		log.Printf("DB error confirming booking %s: %v", req.RequestID, err)
+		return err
+	}
+
+	if !confirmed {
+		var current models.Booking
+		if err := deps.DB.Select("status").Where("request_id = ?", req.RequestID).First(&current).Error; err != nil {
+			log.Printf("DB error loading booking %s after payment: %v", req.RequestID, err)
+			return err
+		}
+
+		// an earlier delivery confirmed it; anything else means the booking
+		// was cancelled while the charge went through, and whoever cancelled
+		// it already gave the seats back
+		if current.Status != "confirmed" {
+			if err := deps.Payments.Refund(ctx, ref, booking.Price); err != nil {
+				log.Printf("Refund error for request %s: %v", req.RequestID, err)
+			}
+			log.Printf("Request %s was %s while paying, payment refunded", req.RequestID, current.Status)
+			return nil
+		}
+	}
+
+	prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, "state3")
+	if err != nil {
+		log.Printf("CAS error: %v", err)
+		return err
+	}
+	if prev == "cancelled" {
+		if err := cancelBooking(deps, req); err != nil {
+			return err
+		}
+		releaseSeats(ctx, deps.RedisSeats, req)
+		releasePromo(ctx, deps.RedisPrice, req.RequestID)
+		if err := deps.Payments.Refund(ctx, ref, booking.Price); err != nil {
+			log.Printf("Refund error for request %s: %v", req.RequestID, err)
+		}
+		log.Printf("Request %s cancelled after payment, refunded and seats reverted", req.RequestID)
+		return nil
+	}
+
+	req.State = "state3"
+	return stateHandlerFunc3(ctx, req, deps)
+}
+
+func setBookingStatus(deps *models.ProcessorDeps, requestID, status string) {
+	if err := deps.DB.Model(&models.Booking{}).
+		Where("request_id = ?", requestID).
+		Update("status", status).Error; err != nil {
+		log.Printf("DB error setting booking %s to %s: %v", requestID, status, err)
+	}
+}
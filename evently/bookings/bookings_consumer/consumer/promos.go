@@ -0,0 +1,179 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"bookings_consumer/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// promoRedemptionTTL keeps a request's redemption around long enough to
+// price its booking and to give the use back if the booking falls through.
+const promoRedemptionTTL = 24 * time.Hour
+
+// errPromoRejected marks a code the booking cannot use, as opposed to a
+// Redis failure worth retrying.
+var errPromoRejected = errors.New("promo code rejected")
+
+// redeemPromoScript counts one use of a code for a request, at most once per
+// request, unless the code or the user has run out of uses. It returns 1 on
+// success, 0 when the code is used up and -1 when the user is.
+var redeemPromoScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[3]) == 1 then
+    return 1
+end
+local maxUses = tonumber(ARGV[1])
+local perUser = tonumber(ARGV[2])
+if maxUses > 0 and tonumber(redis.call("GET", KEYS[1]) or "0") >= maxUses then
+    return 0
+end
+if perUser > 0 and tonumber(redis.call("GET", KEYS[2]) or "0") >= perUser then
+    return -1
+end
+redis.call("INCR", KEYS[1])
+redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[3], ARGV[3], "EX", ARGV[4])
+return 1
+`)
+
+// releasePromoScript gives a request's use back, once.
+var releasePromoScript = redis.NewScript(`
+if redis.call("DEL", KEYS[3]) == 0 then
+    return 0
+end
+redis.call("DECR", KEYS[1])
+redis.call("DECR", KEYS[2])
+return 1
+`)
+
+// promoRedemption is what a request locked in when it redeemed a code, so
+// later changes to the code do not reprice a booking already under way.
+type promoRedemption struct {
+	Code   string  `json:"code"`
+	UserID string  `json:"user_id"`
+	Type   string  `json:"type"`
+	Value  float64 `json:"value"`
+}
+
+func promoKeys(code, userID, requestID string) []string {
+	return []string{
+		"promoUses:" + code,
+		"promoUserUses:" + code + ":" + userID,
+		"promoRedemption:" + requestID,
+	}
+}
+
+// redeemPromo checks the request's code against the definition the events
+// service publishes under promo:<CODE> and counts the use.
+func redeemPromo(ctx context.Context, rdb *redis.Client, req models.KafkaEvent) error {
+	code := strings.ToUpper(strings.TrimSpace(req.PromoCode))
+
+	val, err := rdb.Get(ctx, "promo:"+code).Result()
+	if err == redis.Nil {
+		return fmt.Errorf("%w: unknown code", errPromoRejected)
+	}
+	if err != nil {
+		return err
+	}
+
+	var promo models.PromoCode
+	if err := json.Unmarshal([]byte(val), &promo); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !promo.Active || now.Before(promo.ValidFrom) || !now.Before(promo.ValidUntil) {
+		return fmt.Errorf("%w: code is not active", errPromoRejected)
+	}
+
+	if len(promo.EventIDs) > 0 && !containsString(promo.EventIDs, req.EventID) {
+		return fmt.Errorf("%w: code does not apply to this event", errPromoRejected)
+	}
+
+	redemption, err := json.Marshal(promoRedemption{Code: code, UserID: req.UserID, Type: promo.Type, Value: promo.Value})
+	if err != nil {
+		return err
+	}
+
+	res, err := redeemPromoScript.Run(ctx, rdb, promoKeys(code, req.UserID, req.RequestID),
+		promo.MaxUses, promo.PerUserLimit, redemption, int(promoRedemptionTTL.Seconds())).Int()
+	if err != nil {
+		return err
+	}
+
+	switch res {
+	case 0:
+		return fmt.Errorf("%w: code has been used up", errPromoRejected)
+	case -1:
+		return fmt.Errorf("%w: user has used the code the maximum number of times", errPromoRejected)
+	}
+
+	return nil
+}
+
+// releasePromo gives back the use a request took when its booking fails,
+// is cancelled or expires before it is paid for.
+func releasePromo(ctx context.Context, rdb *redis.Client, requestID string) {
+	redemption, ok := loadRedemption(ctx, rdb, requestID)
+	if !ok {
+		return
+	}
+
+	if err := releasePromoScript.Run(ctx, rdb, promoKeys(redemption.Code, redemption.UserID, requestID)).Err(); err != nil {
+		log.Printf("Redis error releasing promo code for %s: %v", requestID, err)
+	}
+}
+
+func loadRedemption(ctx context.Context, rdb *redis.Client, requestID string) (promoRedemption, bool) {
+	var redemption promoRedemption
+
+	val, err := rdb.Get(ctx, "promoRedemption:"+requestID).Result()
+	if err != nil {
+		return redemption, false
+	}
+
+	if err := json.Unmarshal([]byte(val), &redemption); err != nil {
+		log.Printf("Invalid promo redemption for %s: %v", requestID, err)
+		return redemption, false
+	}
+
+	return redemption, true
+}
+
+// promoDiscount works out how much a request's redeemed code takes off a
+// booking's price. Fixed discounts never take it below zero.
+func promoDiscount(ctx context.Context, rdb *redis.Client, requestID string, price float64) (string, float64) {
+	redemption, ok := loadRedemption(ctx, rdb, requestID)
+	if !ok {
+		return "", 0
+	}
+
+	return redemption.Code, redemption.discount(price)
+}
+
+// discount is what the redemption takes off price, rounded to the cent.
+func (r promoRedemption) discount(price float64) float64 {
+	discount := r.Value
+	if r.Type == "percent" {
+		discount = price * r.Value / 100
+	}
+
+	return math.Round(math.Min(discount, price)*100) / 100
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
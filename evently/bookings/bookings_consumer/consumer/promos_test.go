@@ -0,0 +1,27 @@
+package consumer
+
+import "testing"
+
+func TestPromoRedemptionDiscount(t *testing.T) {
+	tests := []struct {
+		name       string
+		redemption promoRedemption
+		price      float64
+		want       float64
+	}{
+		{"percent", promoRedemption{Type: "percent", Value: 10}, 80, 8},
+		{"percent rounds to the cent", promoRedemption{Type: "percent", Value: 15}, 33.33, 5},
+		{"full percent", promoRedemption{Type: "percent", Value: 100}, 42.5, 42.5},
+		{"fixed", promoRedemption{Type: "fixed", Value: 5}, 80, 5},
+		{"fixed above the price", promoRedemption{Type: "fixed", Value: 50}, 30, 30},
+		{"free booking", promoRedemption{Type: "fixed", Value: 5}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.redemption.discount(tt.price); got != tt.want {
+				t.Fatalf("discount(%v) = %v, want %v", tt.price, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"bookings_consumer/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// checkOnSale reads the status and sales window the events service keeps
+// under sales:<eventId> and says why the event cannot be booked right now,
+// or "" when it can. Events without the key predate lifecycle states and
+// stay bookable.
+func checkOnSale(ctx context.Context, redisPrice *redis.Client, eventID string) (string, error) {
+	val, err := redisPrice.Get(ctx, "sales:"+eventID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var window models.SalesWindow
+	if err := json.Unmarshal([]byte(val), &window); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	switch {
+	case window.Status != "sales_open":
+		return "event is " + window.Status, nil
+	case window.SalesStart != nil && now.Before(*window.SalesStart):
+		return "sales have not started", nil
+	case window.SalesEnd != nil && !now.Before(*window.SalesEnd):
+		return "sales have ended", nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,63 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"bookings_consumer/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// joinWaitlist parks a request that could not get seats. A request that is
+// already on the list (it was promoted and lost the race again) goes back
+// to waiting and keeps its original place in the queue.
+func joinWaitlist(ctx context.Context, req models.KafkaEvent, deps *models.ProcessorDeps) bool {
+	reqKey := "bookingRequest:" + req.RequestID
+
+	req.State = ""
+	payload, err := json.Marshal(req)
+	if err != nil {
+		log.Printf("Failed to marshal waitlist payload for %s: %v", req.RequestID, err)
+		return false
+	}
+
+	entry := models.WaitlistEntry{
+		RequestID: req.RequestID,
+		UserID:    req.UserID,
+		EventID:   req.EventID,
+		Tier:      req.Tier,
+		Seats:     req.Seats,
+		Payload:   string(payload),
+		Status:    "waiting",
+	}
+
+	if err := deps.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "request_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"status": "waiting"}),
+	}).Create(&entry).Error; err != nil {
+		log.Printf("DB error adding %s to waitlist: %v", req.RequestID, err)
+		return false
+	}
+
+	prev, err := compareAndSetState(ctx, deps.RedisReq, reqKey, "waitlisted")
+	if err != nil {
+		log.Printf("CAS error: %v", err)
+		return true
+	}
+	// waiting can outlast stateTTL, so the state must not expire underneath it
+	deps.RedisReq.Persist(ctx, reqKey)
+
+	if prev == "cancelled" {
+		setState(ctx, deps.RedisReq, reqKey, "cancelled", stateTTL)
+		deps.DB.Model(&models.WaitlistEntry{}).
+			Where("request_id = ?", req.RequestID).
+			Update("status", "cancelled")
+		log.Printf("Request %s cancelled while joining the waitlist", req.RequestID)
+		return true
+	}
+
+	log.Printf("Request %s waitlisted for event %s", req.RequestID, req.EventID)
+	return true
+}
@@ -3,8 +3,10 @@ package kafka
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/segmentio/kafka-go"
@@ -12,7 +14,9 @@ import (
 )
 
 type Reader struct {
-	reader *kafka.Reader
+	reader     *kafka.Reader
+	retry      RetryPolicy
+	deadLetter *Producer
 }
 
 // NewReader creates a Kafka reader compatible with Confluent Cloud
@@ -43,14 +47,24 @@ func NewReader(broker, topic, groupID string) *Reader {
 		StartOffset: kafka.FirstOffset,
 	})
 
-	return &Reader{reader: reader}
+	return &Reader{reader: reader, retry: RetryPolicyFromEnv()}
 }
 
-// Start begins consuming messages and calls the handler for each one
-// Commits offsets ONLY after handler succeeds
+// EnableDeadLetter makes Start retry failed messages with exponential
+// backoff and then move them to <topic>.dlq through producer.
+func (r *Reader) EnableDeadLetter(producer *Producer, policy RetryPolicy) {
+	r.deadLetter = producer
+	r.retry = policy
+}
+
+// Start begins consuming messages and calls the handler for each one.
+// A failing message is retried per the reader's RetryPolicy and, once the
+// retries run out or the error is Permanent, published to the dead letter
+// topic. Offsets are
+// committed only after the handler succeeds or the message is dead-lettered.
 func (r *Reader) Start(ctx context.Context, handler func(key, value []byte) error) {
 	for {
-		m, err := r.reader.ReadMessage(ctx)
+		m, err := r.reader.FetchMessage(ctx)
 		if err != nil {
 			if ctx.Err() != nil {
 				log.Println("Kafka reader stopped:", ctx.Err())
@@ -65,10 +79,22 @@ func (r *Reader) Start(ctx context.Context, handler func(key, value []byte) erro
 			m.Topic, m.Partition, m.Offset, string(m.Key), string(m.Value))
 
 		if handler != nil {
-			if err := handler(m.Key, m.Value); err != nil {
-				log.Println("Handler error, offset not committed:", err)
-				// optionally: retry here
-				continue
+			attempts, err := r.handleWithRetry(ctx, m, handler)
+			if err != nil {
+				if ctx.Err() != nil {
+					log.Println("Kafka reader stopped:", ctx.Err())
+					return
+				}
+
+				if r.deadLetter == nil {
+					log.Println("Handler error, offset not committed:", err)
+					continue
+				}
+
+				if dlqErr := r.sendToDeadLetter(m, err, attempts); dlqErr != nil {
+					log.Println("Failed to publish to dead letter topic, offset not committed:", dlqErr)
+					continue
+				}
 			}
 
 			// Commit offset AFTER successful processing
@@ -81,6 +107,61 @@ func (r *Reader) Start(ctx context.Context, handler func(key, value []byte) erro
 	}
 }
 
+// permanentError is a handler error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one no retry can fix, such as a message that does
+// not decode, so Start dead-letters the message without retrying it.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func (r *Reader) handleWithRetry(ctx context.Context, m kafka.Message, handler func(key, value []byte) error) (int, error) {
+	attempts := 0
+	for {
+		attempts++
+		err := handler(m.Key, m.Value)
+		if err == nil {
+			return attempts, nil
+		}
+
+		var permanent permanentError
+		if attempts > r.retry.MaxRetries || errors.As(err, &permanent) {
+			return attempts, err
+		}
+
+		backoff := r.retry.Backoff(attempts)
+		log.Printf("Handler error (attempt %d), retrying in %s: %v", attempts, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (r *Reader) sendToDeadLetter(m kafka.Message, handlerErr error, attempts int) error {
+	headers := append([]kafka.Header{}, m.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(m.Topic)},
+		kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(m.Partition))},
+		kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(m.Offset, 10))},
+		kafka.Header{Key: HeaderError, Value: []byte(handlerErr.Error())},
+		kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	log.Printf("Moving message to dead letter topic %s after %d attempts: %v", DeadLetterTopic(m.Topic), attempts, handlerErr)
+	return r.deadLetter.PublishWithHeaders(DeadLetterTopic(m.Topic), m.Key, m.Value, headers)
+}
+
 // Close closes the Kafka reader
 func (r *Reader) Close() error {
 	return r.reader.Close()
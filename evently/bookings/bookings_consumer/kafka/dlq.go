@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	HeaderOriginalTopic     = "dlq-original-topic"
+	HeaderOriginalPartition = "dlq-original-partition"
+	HeaderOriginalOffset    = "dlq-original-offset"
+	HeaderError             = "dlq-error"
+	HeaderAttempts          = "dlq-attempts"
+	HeaderFailedAt          = "dlq-failed-at"
+
+	deadLetterSuffix = ".dlq"
+	replayIdleWait   = 5 * time.Second
+)
+
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// RetryPolicyFromEnv reads KAFKA_MAX_RETRIES, KAFKA_RETRY_BACKOFF_MS and
+// KAFKA_RETRY_MAX_BACKOFF_MS, falling back to 3 retries starting at 500ms
+// and capped at 30s.
+func RetryPolicyFromEnv() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     envInt("KAFKA_MAX_RETRIES", 3),
+		InitialBackoff: time.Duration(envInt("KAFKA_RETRY_BACKOFF_MS", 500)) * time.Millisecond,
+		MaxBackoff:     time.Duration(envInt("KAFKA_RETRY_MAX_BACKOFF_MS", 30000)) * time.Millisecond,
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+func DeadLetterTopic(topic string) string {
+	return topic + deadLetterSuffix
+}
+
+// ReplayDeadLetters moves messages from <topic>.dlq back to the topic they
+// failed on. It stops after max messages, or once the dead letter topic has
+// been idle for a few seconds, and returns how many were replayed.
+func ReplayDeadLetters(ctx context.Context, broker, topic, groupID string, producer *Producer, max int) (int, error) {
+	reader := NewReader(broker, DeadLetterTopic(topic), groupID+"-dlq-replay")
+	defer reader.Close()
+
+	replayed := 0
+	for max <= 0 || replayed < max {
+		fetchCtx, cancel := context.WithTimeout(ctx, replayIdleWait)
+		m, err := reader.reader.FetchMessage(fetchCtx)
+		cancel()
+
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				break
+			}
+			return replayed, err
+		}
+
+		target := topic
+		var headers []kafka.Header
+		for _, h := range m.Headers {
+			if h.Key == HeaderOriginalTopic {
+				target = string(h.Value)
+			}
+			if !strings.HasPrefix(h.Key, "dlq-") {
+				headers = append(headers, h)
+			}
+		}
+
+		if err := producer.PublishWithHeaders(target, m.Key, m.Value, headers); err != nil {
+			return replayed, err
+		}
+
+		if err := reader.reader.CommitMessages(ctx, m); err != nil {
+			return replayed, err
+		}
+
+		replayed++
+		log.Printf("Replayed dead letter offset=%d to %s", m.Offset, target)
+	}
+
+	return replayed, nil
+}
+
+func envInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
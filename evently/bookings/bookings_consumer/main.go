@@ -4,10 +4,12 @@ import (
 	"bookings_consumer/consumer"
 	"bookings_consumer/kafka"
 	"bookings_consumer/models"
+	"bookings_consumer/payment"
 	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -18,8 +20,17 @@ import (
 func main() {
 	kafkaBrokers := mustGetEnv("KAFKA_BROKERS")
 	topic := mustGetEnv("TOPIC_BOOKINGS_REQUESTS")
+	exchangeTopic := mustGetEnv("TOPIC_EXCHANGE_REQUESTS")
 	group := mustGetEnv("BOOKINGS_CONSUMER_GROUP")
 
+	if len(os.Args) > 1 && os.Args[1] == "replay-dlq" {
+		replayDeadLetters(kafkaBrokers, []string{topic, exchangeTopic}, map[string]string{
+			topic:         group,
+			exchangeTopic: group + "-exchanges",
+		}, os.Args[2:])
+		return
+	}
+
 	redisReq := newRedisClient(mustGetEnv("REDIS_REQUESTS_HOST"), mustGetEnv("REDIS_REQUESTS_PORT"), mustGetEnv("REDIS_REQUESTS_PASSWORD"))
 
 	redisSeats := newRedisClient(mustGetEnv("REDIS_SEATS_HOST"), mustGetEnv("REDIS_SEATS_PORT"), mustGetEnv("REDIS_SEATS_PASSWORD"))
@@ -53,20 +64,40 @@ func main() {
 		log.Fatal("Failed to connect to bookings database:", err)
 	}
 
+	if err := db.AutoMigrate(&models.Booking{}, &models.WaitlistEntry{}, &models.OutboxMessage{}, &models.IdempotencyKey{}, &models.Exchange{}); err != nil {
+		log.Fatal("Failed to migrate bookings tables:", err)
+	}
+
 	log.Println("Starting Bookings Consumer...")
 
 	producer := kafka.NewProducer(kafkaBrokers)
 
+	providerName, _ := os.LookupEnv("PAYMENT_PROVIDER")
+
+	declineRate := 0.0
+	if rate, ok := os.LookupEnv("PAYMENT_FAKE_DECLINE_RATE"); ok && rate != "" {
+		declineRate, err = strconv.ParseFloat(rate, 64)
+		if err != nil {
+			log.Fatalf("Invalid PAYMENT_FAKE_DECLINE_RATE %q", rate)
+		}
+	}
+
+	payments, err := payment.NewProvider(providerName, declineRate)
+	if err != nil {
+		log.Fatal("Failed to create payment provider:", err)
+	}
+
 	deps := models.ProcessorDeps{
 		RedisReq: redisReq,
 		RedisPrice: redisPrice,
 		RedisSeats: redisSeats,
 		DB: db,
 		Producer: producer,
+		Payments: payments,
 	}
 
 
-	consumer.StartBookingConsumer(kafkaBrokers, topic, group, &deps)
+	consumer.StartBookingConsumer(kafkaBrokers, topic, exchangeTopic, group, &deps)
 }
 
 func newRedisClient(host, port, password string) *redis.Client {
@@ -90,3 +121,40 @@ func mustGetEnv(key string) string {
 	}
 	return value
 }
+
+// replayDeadLetters is the replay-dlq admin command:
+//
+//	replay-dlq [topic] [count]
+//
+// It moves up to count dead-lettered messages (all of them by default) back
+// onto the topic, which must be one this consumer reads and defaults to the
+// first of them. groups maps each topic to the consumer group reading it.
+func replayDeadLetters(broker string, topics []string, groups map[string]string, args []string) {
+	topic := topics[0]
+	if len(args) > 0 {
+		if _, err := strconv.Atoi(args[0]); err != nil {
+			topic = args[0]
+			args = args[1:]
+		}
+	}
+	group, ok := groups[topic]
+	if !ok {
+		log.Fatalf("Unknown topic %q, expected one of %v", topic, topics)
+	}
+
+	max := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid message count %q", args[0])
+		}
+		max = n
+	}
+
+	producer := kafka.NewProducer(broker)
+	n, err := kafka.ReplayDeadLetters(context.Background(), broker, topic, group, producer, max)
+	if err != nil {
+		log.Fatalf("Replayed %d messages before failing: %v", n, err)
+	}
+	log.Printf("Replayed %d messages from %s", n, kafka.DeadLetterTopic(topic))
+}
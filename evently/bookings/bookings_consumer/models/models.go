@@ -3,34 +3,154 @@ package models
 import(
 	"time"
 	"bookings_consumer/kafka" 
+	"bookings_consumer/payment"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 
+// Booking is unique per request, event and tier, so a redelivered request
+// cannot insert a second row while an order still gets one row per item.
 type Booking struct {
+	ID            string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RequestID     string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_booking_request_event_tier" json:"requestId"`
+	OrderID       string     `gorm:"type:varchar(255);index" json:"orderId,omitempty"`
+	UserID        string     `gorm:"type:varchar(255);not null" json:"userId"`
+	EventID       string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_booking_request_event_tier" json:"eventId"`
+	Tier          string     `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_booking_request_event_tier" json:"tier,omitempty"`
+	UnitPrice     float64    `gorm:"type:numeric;not null;default:0" json:"unitPrice"`
+	Price         float64    `gorm:"type:numeric;not null" json:"price"`
+	PromoCode     string     `gorm:"type:varchar(100)" json:"promoCode,omitempty"`
+	Discount      float64    `gorm:"type:numeric;not null;default:0" json:"discount"`
+	Seats         int64      `gorm:"not null" json:"seats"`
+	SeatIDs       string     `gorm:"type:text" json:"seatIds"`
+	Status        string     `gorm:"type:varchar(50);not null" json:"status"`
+	HoldExpiresAt *time.Time `gorm:"index" json:"holdExpiresAt,omitempty"`
+	PaymentRef    string     `gorm:"type:varchar(255)" json:"paymentRef,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// IdempotencyKey records every request id a user has booked under, so a
+// request redelivered or retried after its Redis state expired is never
+// booked twice. A request id belongs to the first user who claims it.
+type IdempotencyKey struct {
+	UserID    string    `gorm:"type:varchar(255);not null;index" json:"userId"`
+	RequestID string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_request" json:"requestId"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// WaitlistEntry is a booking request parked until seats free up for its
+// event. Payload is the original booking message, republished as is.
+type WaitlistEntry struct {
 	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	RequestID string    `gorm:"type:varchar(255);not null" json:"requestId"`
+	RequestID string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"requestId"`
 	UserID    string    `gorm:"type:varchar(255);not null" json:"userId"`
-	EventID   string    `gorm:"type:varchar(255);not null" json:"eventId"`
-	Price     float64   `gorm:"type:numeric;not null" json:"price"`
+	EventID   string    `gorm:"type:varchar(255);not null;index" json:"eventId"`
+	Tier      string    `gorm:"type:varchar(100);not null;default:''" json:"tier,omitempty"`
 	Seats     int64     `gorm:"not null" json:"seats"`
+	Payload   string    `gorm:"type:text;not null" json:"-"`
 	Status    string    `gorm:"type:varchar(50);not null" json:"status"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 }
 
+// OutboxMessage is a Kafka message written in the same transaction as the
+// booking change it describes and published later by the outbox relay.
+type OutboxMessage struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Topic         string     `gorm:"type:varchar(255);not null" json:"topic"`
+	Key           string     `gorm:"type:varchar(255);not null" json:"key"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"lastError,omitempty"`
+	NextAttemptAt time.Time  `gorm:"index" json:"nextAttemptAt"`
+	PublishedAt   *time.Time `gorm:"index" json:"publishedAt,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
+
 type KafkaEvent struct {
 	RequestID string `json:"request_id"`
 	EventID   string `json:"event_id"`
+	Tier      string `json:"tier,omitempty"`
 	Seats     int64  `json:"seats"`
+	SeatIDs   []string `json:"seat_ids"`
 	UserID    string `json:"user_id"`
 	Price float64 `json:"price"`
+	HoldMinutes int64 `json:"hold_minutes"`
+	Waitlist  bool   `json:"waitlist"`
+	PromoCode string `json:"promo_code,omitempty"`
+	Items     []OrderItem `json:"items,omitempty"`
+	Action    string `json:"action"`
 	State     string `json:"state"`
 }
 
+// KafkaExchangeEvent asks to move a confirmed booking to another event,
+// usually another showing of the same one. All of the booking's seats move;
+// SeatIDs picks them on a target event with a seat map.
+type KafkaExchangeEvent struct {
+	RequestID string   `json:"request_id"`
+	UserID    string   `json:"user_id"`
+	BookingID string   `json:"booking_id"`
+	EventID   string   `json:"event_id"`
+	Tier      string   `json:"tier,omitempty"`
+	SeatIDs   []string `json:"seat_ids,omitempty"`
+}
+
+// Exchange records one exchange request and how it ended. PriceDifference
+// is what the customer paid on top, or got back when negative.
+type Exchange struct {
+	ID              string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RequestID       string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"requestId"`
+	UserID          string    `gorm:"type:varchar(255);not null" json:"userId"`
+	FromBookingID   string    `gorm:"type:varchar(255);not null;index" json:"fromBookingId"`
+	ToBookingID     string    `gorm:"type:varchar(255)" json:"toBookingId,omitempty"`
+	FromEventID     string    `gorm:"type:varchar(255);not null" json:"fromEventId"`
+	ToEventID       string    `gorm:"type:varchar(255);not null" json:"toEventId"`
+	ToTier          string    `gorm:"type:varchar(100);not null;default:''" json:"toTier,omitempty"`
+	Seats           int64     `gorm:"not null" json:"seats"`
+	OldPrice        float64   `gorm:"type:numeric;not null;default:0" json:"oldPrice"`
+	NewPrice        float64   `gorm:"type:numeric;not null;default:0" json:"newPrice"`
+	PriceDifference float64   `gorm:"type:numeric;not null;default:0" json:"priceDifference"`
+	PaymentRef      string    `gorm:"type:varchar(255)" json:"paymentRef,omitempty"`
+	Status          string    `gorm:"type:varchar(50);not null" json:"status"`
+	Reason          string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// OrderItem is one event's part of a multi-event order. The whole order is
+// booked and paid for as one request or not at all.
+type OrderItem struct {
+	EventID string `json:"event_id"`
+	Tier    string `json:"tier,omitempty"`
+	Seats   int64  `json:"seats"`
+}
+
+// SalesWindow is the lifecycle status and sales window the events service
+// publishes under sales:<eventId>.
+type SalesWindow struct {
+	Status     string     `json:"status"`
+	SalesStart *time.Time `json:"sales_start,omitempty"`
+	SalesEnd   *time.Time `json:"sales_end,omitempty"`
+}
+
+// PromoCode is the definition the events service publishes under
+// promo:<CODE>.
+type PromoCode struct {
+	Code         string    `json:"code"`
+	Type         string    `json:"type"`
+	Value        float64   `json:"value"`
+	MaxUses      int64     `json:"max_uses"`
+	PerUserLimit int64     `json:"per_user_limit"`
+	ValidFrom    time.Time `json:"valid_from"`
+	ValidUntil   time.Time `json:"valid_until"`
+	EventIDs     []string  `json:"event_ids"`
+	Active       bool      `json:"active"`
+}
+
 type KafkaUpdateEvent struct {
 	EventId   string `json:"event_id"`
+	Tier      string `json:"tier,omitempty"`
 	Seats     int64  `json:"seats"`
 	Operation string `json:"operation"`
 }
@@ -41,4 +161,5 @@ type ProcessorDeps struct {
     RedisPrice *redis.Client
     DB         *gorm.DB
     Producer   *kafka.Producer
+    Payments   payment.PaymentProvider
 }
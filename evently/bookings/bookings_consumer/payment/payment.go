@@ -0,0 +1,117 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrDeclined         = errors.New("payment declined")
+	ErrUnknownReference = errors.New("unknown payment reference")
+	ErrRefundTooLarge   = errors.New("refund is more than what is left of the charge")
+)
+
+type ChargeRequest struct {
+	RequestID string
+	UserID    string
+	Amount    float64
+}
+
+// PaymentProvider charges customers for bookings. Charge must be idempotent
+// on RequestID because Kafka can redeliver a booking message.
+type PaymentProvider interface {
+	Charge(ctx context.Context, req ChargeRequest) (string, error)
+	Refund(ctx context.Context, reference string, amount float64) error
+}
+
+// NewProvider returns the provider named by PAYMENT_PROVIDER. declineRate
+// is the share of charges, from 0 to 1, the fake provider declines
+// (PAYMENT_FAKE_DECLINE_RATE).
+func NewProvider(name string, declineRate float64) (PaymentProvider, error) {
+	switch name {
+	case "", "fake":
+		if declineRate < 0 || declineRate > 1 {
+			return nil, fmt.Errorf("fake decline rate must be between 0 and 1, got %v", declineRate)
+		}
+		return NewFakeProvider(declineRate), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
+
+const fakeReferencePrefix = "fake_"
+
+// FakeProvider approves charges with a reference derived from the request
+// id and declines declineRate of them, which is handy for exercising the
+// payment_failed path. Whether a request is declined depends only on its
+// id, so a redelivered charge gets the same answer. Refunds must name a
+// fake reference and cannot add up to more than the charge it made.
+type FakeProvider struct {
+	declineRate float64
+
+	mu       sync.Mutex
+	charged  map[string]float64
+	refunded map[string]float64
+}
+
+func NewFakeProvider(declineRate float64) *FakeProvider {
+	return &FakeProvider{
+		declineRate: declineRate,
+		charged:     make(map[string]float64),
+		refunded:    make(map[string]float64),
+	}
+}
+
+func (p *FakeProvider) Charge(ctx context.Context, req ChargeRequest) (string, error) {
+	if p.declines(req.RequestID) {
+		return "", ErrDeclined
+	}
+
+	ref := fakeReferencePrefix + req.RequestID
+
+	p.mu.Lock()
+	p.charged[ref] = req.Amount
+	p.mu.Unlock()
+
+	return ref, nil
+}
+
+// Refund pays back part or all of a charge. Charges made before the
+// process started are not known, so only their reference is checked.
+func (p *FakeProvider) Refund(ctx context.Context, reference string, amount float64) error {
+	if !strings.HasPrefix(reference, fakeReferencePrefix) {
+		return ErrUnknownReference
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charged, known := p.charged[reference]
+	if known && p.refunded[reference]+amount > charged {
+		return ErrRefundTooLarge
+	}
+
+	p.refunded[reference] += amount
+	return nil
+}
+
+// Refunded is how much of a charge has been paid back so far.
+func (p *FakeProvider) Refunded(reference string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refunded[reference]
+}
+
+func (p *FakeProvider) declines(requestID string) bool {
+	if p.declineRate <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(requestID))
+	return float64(h.Sum32()%10000)/10000 < p.declineRate
+}
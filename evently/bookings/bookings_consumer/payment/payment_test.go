@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFakeProviderCharge(t *testing.T) {
+	p := NewFakeProvider(0)
+
+	ref, err := p.Charge(context.Background(), ChargeRequest{RequestID: "req-1", UserID: "user-1", Amount: 40})
+	if err != nil {
+		t.Fatalf("charge failed: %v", err)
+	}
+	if ref != "fake_req-1" {
+		t.Fatalf("reference = %q, want fake_req-1", ref)
+	}
+
+	again, err := p.Charge(context.Background(), ChargeRequest{RequestID: "req-1", UserID: "user-1", Amount: 40})
+	if err != nil || again != ref {
+		t.Fatalf("redelivered charge = %q, %v; want %q, nil", again, err, ref)
+	}
+}
+
+func TestFakeProviderDecline(t *testing.T) {
+	always := NewFakeProvider(1)
+	if _, err := always.Charge(context.Background(), ChargeRequest{RequestID: "req-1", Amount: 10}); !errors.Is(err, ErrDeclined) {
+		t.Fatalf("charge with decline rate 1 = %v, want ErrDeclined", err)
+	}
+
+	half := NewFakeProvider(0.5)
+	declined := 0
+	for i := 0; i < 1000; i++ {
+		req := ChargeRequest{RequestID: fmt.Sprintf("req-%d", i), Amount: 10}
+
+		_, first := half.Charge(context.Background(), req)
+		_, second := half.Charge(context.Background(), req)
+		if errors.Is(first, ErrDeclined) != errors.Is(second, ErrDeclined) {
+			t.Fatalf("request %s was declined only once", req.RequestID)
+		}
+		if errors.Is(first, ErrDeclined) {
+			declined++
+		}
+	}
+
+	if declined < 400 || declined > 600 {
+		t.Fatalf("declined %d of 1000 charges at rate 0.5", declined)
+	}
+}
+
+func TestFakeProviderRefundAfterCancel(t *testing.T) {
+	p := NewFakeProvider(0)
+
+	ref, err := p.Charge(context.Background(), ChargeRequest{RequestID: "req-1", Amount: 40})
+	if err != nil {
+		t.Fatalf("charge failed: %v", err)
+	}
+
+	// a booking cancelled after payment gets the whole charge back
+	if err := p.Refund(context.Background(), ref, 40); err != nil {
+		t.Fatalf("refund failed: %v", err)
+	}
+	if got := p.Refunded(ref); got != 40 {
+		t.Fatalf("refunded %v, want 40", got)
+	}
+
+	if err := p.Refund(context.Background(), ref, 1); !errors.Is(err, ErrRefundTooLarge) {
+		t.Fatalf("second refund = %v, want ErrRefundTooLarge", err)
+	}
+
+	if err := p.Refund(context.Background(), "card_123", 10); !errors.Is(err, ErrUnknownReference) {
+		t.Fatalf("refund of a foreign reference = %v, want ErrUnknownReference", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if _, err := NewProvider("fake", 0.25); err != nil {
+		t.Fatalf("fake provider: %v", err)
+	}
+	if _, err := NewProvider("fake", 1.5); err == nil {
+		t.Fatal("decline rate above 1 was accepted")
+	}
+	if _, err := NewProvider("stripe", 0); err == nil {
+		t.Fatal("unknown provider was accepted")
+	}
+}
@@ -1,145 +1,381 @@
 package consumer
 
 import (
+	"cancel_consumer/kafka"
 	"cancel_consumer/models"
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"os"
+	"slices"
 
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (p *CancelProcessor) ProcessCancelBookingMessage(ctx context.Context, key, value []byte) error {
 	var msg models.KafkaCancelEvent
 	if err := json.Unmarshal(value, &msg); err != nil {
 		log.Printf("Invalid cancel message: %v", err)
-		return err
+		return kafka.Permanent(err)
+	}
+
+	if msg.UserID == "" {
+		log.Printf("Cancel message missing user_id, ignoring")
+		return nil
 	}
 
 	if msg.BookingRequestId != "" {
-		reqKey := "bookingRequest:" + msg.BookingRequestId
+		return p.cancelRequest(ctx, msg, key)
+	}
 
-		state, err := p.redisReq.Get(ctx, reqKey).Result()
-		if err == redis.Nil {
+	if msg.BookingId != "" {
 
-			err = p.redisReq.Set(ctx, reqKey, "cancelled", 0).Err()
+		log.Printf("Processing cancel by BookingID: %s", msg.BookingId)
 
-			if err != nil {
-				log.Printf("Failed to mark request %s cancelled: %v", msg.BookingRequestId, err)
-				return err
+		var booking models.Booking
+		err := p.db.Select("request_id", "order_id", "status").Where("id = ?", msg.BookingId).First(&booking).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error looking up booking %s: %v", msg.BookingId, err)
+			return err
+		}
+
+		// the bookings consumer is still charging for a payment_pending
+		// booking, so it has to see the request cancelled or it would go on
+		// to confirm seats given back here
+		if err == nil && booking.Status == "payment_pending" {
+			if booking.OrderID != "" {
+				log.Printf("Refusing to cancel booking %s: its order is waiting on payment, cancel the order", msg.BookingId)
+				return nil
 			}
+			msg.BookingId = ""
+			msg.BookingRequestId = booking.RequestID
+			return p.cancelRequest(ctx, msg, key)
+		}
 
-			log.Printf("Request %s not in Redis, cancelling at DB level", msg.BookingRequestId)
+		return p.cancelAtDB(ctx, msg, key)
+	}
+
+	log.Printf("Cancel message missing bookingRequestId and bookingId")
+	return nil
+}
+
+// cancelRequest cancels by request id. A request still being processed is
+// only marked cancelled and the bookings consumer gives its seats back; a
+// stored booking is cancelled here.
+func (p *CancelProcessor) cancelRequest(ctx context.Context, msg models.KafkaCancelEvent, key []byte) error {
+	reqKey := "bookingRequest:" + msg.BookingRequestId
+
+	owners, err := p.requestOwner(ctx, msg.BookingRequestId)
+	if err != nil {
+		log.Printf("Error looking up owner of request %s: %v", msg.BookingRequestId, err)
+		return err
+	}
+	if len(owners) == 0 {
+		log.Printf("Refusing to cancel request %s for user %s: no owner on record", msg.BookingRequestId, msg.UserID)
+		return nil
+	}
+	if !slices.Contains(owners, msg.UserID) {
+		log.Printf("Refusing to cancel request %s for user %s: it belongs to another user", msg.BookingRequestId, msg.UserID)
+		return nil
+	}
+
+	state, err := p.redisReq.Get(ctx, reqKey).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Redis error while fetching state for %s: %v", msg.BookingRequestId, err)
+		return err
+	}
+	missing := err == redis.Nil
+
+	// only a stored booking can give back some of its seats, and the
+	// request stays live while it has seats left
+	if msg.Seats > 0 || len(msg.SeatIDs) > 0 {
+		if missing || state == "success" || state == "held" {
 			return p.cancelAtDB(ctx, msg, key)
+		}
+		log.Printf("Refusing partial cancel of request %s in state %s", msg.BookingRequestId, state)
+		return nil
+	}
+
+	if missing {
 
-		} else if err != nil {
+		err = p.markCancelled(ctx, msg.BookingRequestId)
 
-			log.Printf("Redis error while fetching state for %s: %v", msg.BookingRequestId, err)
+		if err != nil {
+			log.Printf("Failed to mark request %s cancelled: %v", msg.BookingRequestId, err)
 			return err
 		}
 
-		switch state {
-		case "state1", "state2", "state3":
-			// still inflight, mark cancelled
+		log.Printf("Request %s not in Redis, cancelling at DB level", msg.BookingRequestId)
+		return p.cancelAtDB(ctx, msg, key)
+	}
 
-			err = p.redisReq.Set(ctx, reqKey, "cancelled", 0).Err()
+	switch state {
+	case "state1", "state2", "state3", "payment_pending":
+		// still inflight, mark cancelled
 
-			if err != nil {
-				log.Printf("Failed to mark request %s cancelled: %v", msg.BookingRequestId, err)
-				return err
-			}
+		err = p.markCancelled(ctx, msg.BookingRequestId)
 
-			log.Printf("Marked request %s as cancelled", msg.BookingRequestId)
+		if err != nil {
+			log.Printf("Failed to mark request %s cancelled: %v", msg.BookingRequestId, err)
+			return err
+		}
 
-		case "success":
-			// already success -> cancel at DB
+		log.Printf("Marked request %s as cancelled", msg.BookingRequestId)
 
-			err = p.redisReq.Set(ctx, reqKey, "cancelled", 0).Err()
+	case "success", "held":
+		// already success or holding seats -> cancel at DB
 
-			if err != nil {
-				log.Printf("Failed to mark request %s cancelled: %v", msg.BookingRequestId, err)
-				return err
-			}
+		err = p.markCancelled(ctx, msg.BookingRequestId)
 
-			log.Printf("Request %s already success, deleting booking", msg.BookingRequestId)
+		if err != nil {
+			log.Printf("Failed to mark request %s cancelled: %v", msg.BookingRequestId, err)
+			return err
+		}
 
-			return p.cancelAtDB(ctx, msg, key)
+		log.Printf("Request %s already success, deleting booking", msg.BookingRequestId)
 
-		case "failed", "cancelled":
-			log.Printf("Request %s already in terminal state: %s", msg.BookingRequestId, state)
+		return p.cancelAtDB(ctx, msg, key)
+
+	case "waitlisted":
+		err = p.markCancelled(ctx, msg.BookingRequestId)
+
+		if err != nil {
+			log.Printf("Failed to mark request %s cancelled: %v", msg.BookingRequestId, err)
+			return err
 		}
 
-		return nil
-	}
+		if err := p.db.Model(&models.WaitlistEntry{}).
+			Where("request_id = ?", msg.BookingRequestId).
+			Update("status", "cancelled").Error; err != nil {
+			log.Printf("Error removing request %s from waitlist: %v", msg.BookingRequestId, err)
+			return err
+		}
 
-	if msg.BookingId != "" {
+		log.Printf("Removed request %s from the waitlist", msg.BookingRequestId)
 
-		log.Printf("Processing cancel by BookingID: %s", msg.BookingId)
-		return p.cancelAtDB(ctx, msg, key)
+	case "failed", "cancelled", "payment_failed":
+		log.Printf("Request %s already in terminal state: %s", msg.BookingRequestId, state)
 	}
 
-	log.Printf("Cancel message missing bookingRequestId and bookingId")
 	return nil
 }
 
-func (p *CancelProcessor) publishSeatsUpdate(requestId string, msg models.KafkaCancelEvent) error {
+// markCancelled flags an in-flight or finished request as cancelled and
+// tells anyone streaming its status through the gateway.
+func (p *CancelProcessor) markCancelled(ctx context.Context, requestID string) error {
+	if err := p.redisReq.Set(ctx, "bookingRequest:"+requestID, "cancelled", 0).Err(); err != nil {
+		return err
+	}
+	return p.redisReq.Publish(ctx, "bookingStatus:"+requestID, "cancelled").Err()
+}
+
+// seatsUpdateOutbox builds the outbox row that gives the cancelled seats
+// back to available_seats in Mongo.
+func seatsUpdateOutbox(key string, booking models.Booking) (models.OutboxMessage, error) {
 	updateEvent := models.KafkaUpdateEvent{
-		EventId:   msg.EventId,
-		Seats:     msg.Seats,
+		EventId:   booking.EventID,
+		Tier:      booking.Tier,
+		Seats:     booking.Seats,
 		Operation: "add",
 	}
 
 	payload, err := json.Marshal(updateEvent)
 	if err != nil {
-		log.Printf("Failed to marshal seats update event: %v", err)
-		return err
+		return models.OutboxMessage{}, err
 	}
 
 	topic, _ := os.LookupEnv("UPDATE_SEATS_REQUESTS")
+	return models.OutboxMessage{Topic: topic, Key: key, Payload: string(payload)}, nil
+}
 
-	err = p.producer.Publish(
-		topic,
-		[]byte(requestId),
-		payload,
-	)
+// requestOwner returns the users that submitted requestID. A request no
+// consumer has picked up yet is only known to the gateway, which records
+// its owner under requestOwner:<id>; none are returned when neither knows
+// the request.
+func (p *CancelProcessor) requestOwner(ctx context.Context, requestID string) ([]string, error) {
+	var owners []string
+	if err := p.db.Model(&models.IdempotencyKey{}).Where("request_id = ?", requestID).Pluck("user_id", &owners).Error; err != nil {
+		return nil, err
+	}
+	if len(owners) > 0 {
+		return owners, nil
+	}
 
-	if err != nil {
-		log.Printf("Failed to publish seats update event: %v", err)
-	} else {
-		log.Printf("Published seats update event %v", msg)
+	// bookings made before request ids were recorded
+	if err := p.db.Model(&models.Booking{}).Where("request_id = ?", requestID).Distinct().Pluck("user_id", &owners).Error; err != nil {
+		return nil, err
+	}
+	if len(owners) > 0 {
+		return owners, nil
 	}
 
-	return err
+	owner, err := p.redisReq.Get(ctx, "requestOwner:"+requestID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{owner}, nil
 }
 
+// cancellable lists the booking statuses that still hold seats. Mongo only
+// counts confirmed and held bookings; payment_pending ones are taken in
+// Redis alone, and only the first two can be cancelled in part.
+var cancellable = map[string]bool{"confirmed": true, "held": true, "payment_pending": true}
+
 func (p *CancelProcessor) cancelAtDB(ctx context.Context, msg models.KafkaCancelEvent, key []byte) error {
-	seatsKey := "seatsLeft:" + msg.EventId
+	partial := msg.Seats > 0 || len(msg.SeatIDs) > 0
 
-	if msg.BookingId != "" {
-		if err := p.db.Model(&models.Booking{}).Where("id = ?", msg.BookingId).Update("status", "cancelled").Error; err != nil {
-			log.Printf("Error marking booking %s as cancelled: %v", msg.BookingId, err)
+	if msg.BookingId == "" {
+		var orderRows int64
+		if err := p.db.Model(&models.Booking{}).Where("order_id = ?", msg.BookingRequestId).Count(&orderRows).Error; err != nil {
+			log.Printf("Error looking up order %s: %v", msg.BookingRequestId, err)
 			return err
 		}
-		log.Printf("Deleted booking %s from DB", msg.BookingId)
+		if orderRows > 0 && partial {
+			log.Printf("Refusing partial cancel of order %s: cancel its bookings by booking_id", msg.BookingRequestId)
+			return nil
+		}
+		if orderRows > 0 {
+			return p.cancelOrderAtDB(ctx, msg.BookingRequestId, msg.UserID, key)
+		}
+	}
+
+	var booking models.Booking
+	var cancellation models.BookingCancellation
+	refused := ""
+
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if msg.BookingId != "" {
+			query = query.Where("id = ?", msg.BookingId)
+		} else {
+			query = query.Where("request_id = ?", msg.BookingRequestId)
+		}
 
-	} else if msg.BookingRequestId != "" {
-		if err := p.db.Model(&models.Booking{}).Where("request_id = ?", msg.BookingRequestId).Update("status", "cancelled").Error; err != nil {
-			log.Printf("Error marking booking with reqId %s as cancelled : %v", msg.BookingRequestId, err)
+		err := query.First(&booking).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			refused = "booking not found"
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if booking.UserID != msg.UserID {
+			refused = "booking belongs to another user"
+			return nil
+		}
+
+		var applied int64
+		if err := tx.Model(&models.BookingCancellation{}).Where("cancel_request_id = ?", string(key)).Count(&applied).Error; err != nil {
+			return err
+		}
+		if applied > 0 {
+			refused = "cancel request already applied"
+			return nil
+		}
+
+		if !cancellable[booking.Status] {
+			refused = "booking is already " + booking.Status
+			return nil
+		}
+
+		var remainingIDs string
+		cancellation, remainingIDs, refused = planCancellation(booking, msg)
+		if refused != "" {
+			return nil
+		}
+		if cancellation.RemainingSeats > 0 && booking.Status == "payment_pending" {
+			refused = "only confirmed or held bookings can be cancelled in part"
+			return nil
+		}
+
+		value := cancelledValue(booking, cancellation)
+
+		update := map[string]interface{}{"status": "cancelled"}
+		if cancellation.RemainingSeats > 0 {
+			update = map[string]interface{}{
+				"seats":    cancellation.RemainingSeats,
+				"seat_ids": remainingIDs,
+				"price":    booking.Price - value,
+			}
+		}
+		if err := tx.Model(&models.Booking{}).Where("id = ?", booking.ID).Updates(update).Error; err != nil {
 			return err
 		}
-		log.Printf("Deleted booking for requestID %s", msg.BookingRequestId)
-	}
 
-	if msg.Seats > 0 {
-		p.publishSeatsUpdate(string(key), msg)
+		if booking.Status == "confirmed" {
+			refund := p.buildRefund(ctx, booking, value)
+			if err := recordRefund(tx, refund); err != nil {
+				return err
+			}
+			cancellation.Refund = refund.Amount
+			cancellation.RefundPercent = refund.Percent
+			log.Printf("Recorded refund of %.2f (%.0f%%) for %d seats of booking %s", refund.Amount, refund.Percent, cancellation.Seats, booking.ID)
+		}
 
-		if err := p.redisSeats.IncrBy(ctx, seatsKey, int64(msg.Seats)).Err(); err != nil {
-			log.Printf("Error incrementing seats for requestID %s: %v", msg.BookingRequestId, err)
+		cancellation.CancelRequestID = string(key)
+		cancellation.RestorePending = true
+		if err := tx.Create(&cancellation).Error; err != nil {
 			return err
 		}
-		log.Printf("Restored %d seats for eventId %s", msg.Seats, msg.EventId)
+
+		if booking.Status == "confirmed" || booking.Status == "held" {
+			outbox, err := seatsUpdateOutbox(string(key), releasedSeats(booking, cancellation))
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&outbox).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Error cancelling booking (id=%s, reqId=%s): %v", msg.BookingId, msg.BookingRequestId, err)
+		return err
+	}
+
+	// also picks up a cancellation an earlier delivery applied but could
+	// not give the seats of
+	if err := p.restoreCancelledSeats(ctx, true, "cancel_request_id = ?", string(key)); err != nil {
+		log.Printf("Error restoring seats for cancel request %s: %v", string(key), err)
+		return err
+	}
+
+	if refused != "" {
+		log.Printf("Refusing to cancel booking (id=%s, reqId=%s) for user %s: %s", msg.BookingId, msg.BookingRequestId, msg.UserID, refused)
+		return nil
+	}
+
+	if cancellation.RemainingSeats > 0 {
+		log.Printf("Cancelled %d seats of booking %s, %d left", cancellation.Seats, booking.ID, cancellation.RemainingSeats)
+	} else {
+		log.Printf("Cancelled booking %s in DB", booking.ID)
+	}
+
+	// a partial cancel skipped the request state, so settle it once the
+	// whole booking is gone
+	if partial && cancellation.RemainingSeats == 0 && booking.OrderID == "" {
+		if err := p.markCancelled(ctx, booking.RequestID); err != nil {
+			log.Printf("Failed to mark request %s cancelled: %v", booking.RequestID, err)
+		}
 	}
 
 	return nil
 }
+
+// releasedSeats is the part of booking a cancellation gives back.
+func releasedSeats(booking models.Booking, cancellation models.BookingCancellation) models.Booking {
+	booking.Seats = cancellation.Seats
+	booking.SeatIDs = cancellation.SeatIDs
+	return booking
+}
+
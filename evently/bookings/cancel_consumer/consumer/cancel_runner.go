@@ -16,20 +16,27 @@ import (
 type CancelProcessor struct {
 	redisReq   *redis.Client
 	redisSeats *redis.Client
+	redisPrice *redis.Client
 	db         *gorm.DB
 	producer   *kafka.Producer
+
+	// bookingsTopic is where promoted waitlist requests are queued again.
+	bookingsTopic string
 }
 
-func NewCancelProcessor(redisReq, redisSeats *redis.Client, db *gorm.DB, producer *kafka.Producer) *CancelProcessor {
-	return &CancelProcessor{redisReq: redisReq, redisSeats: redisSeats, db: db, producer: producer}
+func NewCancelProcessor(redisReq, redisSeats, redisPrice *redis.Client, db *gorm.DB, producer *kafka.Producer, bookingsTopic string) *CancelProcessor {
+	return &CancelProcessor{redisReq: redisReq, redisSeats: redisSeats, redisPrice: redisPrice, db: db, producer: producer, bookingsTopic: bookingsTopic}
 }
 
 func StartCancelConsumer(
 	broker string,
 	topic string,
+	eventTopic string,
+	bookingsTopic string,
 	groupID string,
 	redisReq *redis.Client,
 	redisSeats *redis.Client,
+	redisPrice *redis.Client,
 	db *gorm.DB,
 	producer *kafka.Producer,
 ) {
@@ -45,15 +52,32 @@ func StartCancelConsumer(
 		cancel()
 	}()
 
+	go StartOutboxRelay(ctx, db, producer)
+
+	processor := NewCancelProcessor(redisReq, redisSeats, redisPrice, db, producer, bookingsTopic)
+	go startEventCancellationConsumer(ctx, broker, eventTopic, groupID, processor, producer)
+
 	reader := kafka.NewReader(broker, topic, groupID)
 	defer reader.Close()
+	reader.EnableDeadLetter(producer, kafka.RetryPolicyFromEnv())
 
 	log.Printf("Kafka consumer started: topic=%s, groupID=%s", topic, groupID)
 
-	processor := NewCancelProcessor(redisReq, redisSeats, db, producer)
+	reader.Start(ctx, func(key, value []byte) error {
+		return processor.ProcessCancelBookingMessage(ctx, key, value)
+	})
+}
+
+// startEventCancellationConsumer handles cancelled events, which come in on
+// their own topic and consumer group and cancel every booking of the event.
+func startEventCancellationConsumer(ctx context.Context, broker, topic, groupID string, processor *CancelProcessor, producer *kafka.Producer) {
+	reader := kafka.NewReader(broker, topic, groupID+"-events")
+	defer reader.Close()
+	reader.EnableDeadLetter(producer, kafka.RetryPolicyFromEnv())
+
+	log.Printf("Kafka event cancellation consumer started: topic=%s, groupID=%s", topic, groupID)
 
 	reader.Start(ctx, func(key, value []byte) error {
-		processor.ProcessCancelBookingMessage(ctx, key, value)
-		return nil
+		return processor.ProcessEventCancellationMessage(ctx, value)
 	})
 }
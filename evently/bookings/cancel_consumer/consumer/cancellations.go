@@ -0,0 +1,79 @@
+package consumer
+
+import (
+	"cancel_consumer/models"
+	"math"
+	"strings"
+)
+
+// planCancellation works out which of the booking's seats msg cancels.
+// Without seats or seat_ids all of them go. Bookings of named seats have to
+// say which seats to cancel unless they cancel everything. It returns the
+// line item, the seat ids left on the booking and why the request was
+// refused, if it was.
+func planCancellation(booking models.Booking, msg models.KafkaCancelEvent) (models.BookingCancellation, string, string) {
+	cancellation := models.BookingCancellation{
+		BookingID: booking.ID,
+		UserID:    booking.UserID,
+		EventID:   booking.EventID,
+		Tier:      booking.Tier,
+		Seats:     booking.Seats,
+		SeatIDs:   booking.SeatIDs,
+	}
+
+	var booked []string
+	if booking.SeatIDs != "" {
+		booked = strings.Split(booking.SeatIDs, ",")
+	}
+
+	switch {
+	case len(msg.SeatIDs) > 0:
+		if len(booked) == 0 {
+			return cancellation, "", "booking has no named seats"
+		}
+
+		cancelled := make(map[string]bool, len(msg.SeatIDs))
+		for _, id := range msg.SeatIDs {
+			cancelled[id] = true
+		}
+
+		var remaining []string
+		for _, id := range booked {
+			if !cancelled[id] {
+				remaining = append(remaining, id)
+			}
+		}
+
+		if len(cancelled) != len(msg.SeatIDs) || len(booked)-len(remaining) != len(cancelled) {
+			return cancellation, "", "seat_ids must be distinct seats of this booking"
+		}
+
+		cancellation.Seats = int64(len(msg.SeatIDs))
+		cancellation.SeatIDs = strings.Join(msg.SeatIDs, ",")
+		cancellation.RemainingSeats = booking.Seats - cancellation.Seats
+		return cancellation, strings.Join(remaining, ","), ""
+
+	case msg.Seats > 0:
+		if msg.Seats > booking.Seats {
+			return cancellation, "", "cannot cancel more seats than the booking has"
+		}
+		if len(booked) > 0 && msg.Seats < booking.Seats {
+			return cancellation, "", "name the seat_ids to cancel"
+		}
+
+		cancellation.Seats = msg.Seats
+		cancellation.RemainingSeats = booking.Seats - msg.Seats
+		return cancellation, "", ""
+	}
+
+	return cancellation, "", ""
+}
+
+// cancelledValue is the part of the booking's price paid for the cancelled
+// seats.
+func cancelledValue(booking models.Booking, cancellation models.BookingCancellation) float64 {
+	if cancellation.RemainingSeats == 0 {
+		return booking.Price
+	}
+	return math.Round(booking.Price*float64(cancellation.Seats)/float64(booking.Seats)*100) / 100
+}
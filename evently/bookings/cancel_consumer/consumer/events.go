@@ -0,0 +1,177 @@
+package consumer
+
+import (
+	"cancel_consumer/kafka"
+	"cancel_consumer/models"
+	"context"
+	"encoding/json"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProcessEventCancellationMessage cancels every booking of an event the
+// organiser cancelled. Paid bookings are refunded in full whatever the
+// event's refund policy says, waiting requests are dropped from the
+// waitlist, and the released seats are not offered to anyone. Each booking
+// has its own line item, so a redelivered message only picks up the
+// bookings the last run missed.
+func (p *CancelProcessor) ProcessEventCancellationMessage(ctx context.Context, value []byte) error {
+	var msg models.KafkaEventCancellation
+	if err := json.Unmarshal(value, &msg); err != nil {
+		log.Printf("Invalid event cancellation message: %v", err)
+		return kafka.Permanent(err)
+	}
+
+	if msg.EventID == "" {
+		log.Printf("Event cancellation message missing event_id, ignoring")
+		return nil
+	}
+
+	if err := p.cancelEventWaitlist(ctx, msg.EventID); err != nil {
+		log.Printf("Error clearing waitlist for event %s: %v", msg.EventID, err)
+		return err
+	}
+
+	var bookings []models.Booking
+	if err := p.db.Where("event_id = ? AND status IN ?", msg.EventID, []string{"confirmed", "held", "payment_pending"}).
+		Find(&bookings).Error; err != nil {
+		log.Printf("Error loading bookings of event %s: %v", msg.EventID, err)
+		return err
+	}
+
+	cancelled := 0
+	for _, booking := range bookings {
+		ok, err := p.cancelForEvent(ctx, booking)
+		if err != nil {
+			log.Printf("Error cancelling booking %s of event %s: %v", booking.ID, msg.EventID, err)
+			return err
+		}
+		if ok {
+			cancelled++
+		}
+	}
+
+	// the seats go back after every booking is cancelled, and again on a
+	// redelivery for whatever an earlier run could not give back
+	if err := p.restoreCancelledSeats(ctx, false, "event_id = ?", msg.EventID); err != nil {
+		log.Printf("Error restoring seats of cancelled event %s: %v", msg.EventID, err)
+		return err
+	}
+
+	log.Printf("Cancelled %d bookings of cancelled event %s", cancelled, msg.EventID)
+	return nil
+}
+
+// cancelEventWaitlist drops every request still waiting for seats of the
+// event and settles their request state.
+func (p *CancelProcessor) cancelEventWaitlist(ctx context.Context, eventID string) error {
+	var requestIDs []string
+	if err := p.db.Model(&models.WaitlistEntry{}).
+		Where("event_id = ? AND status = ?", eventID, "waiting").
+		Pluck("request_id", &requestIDs).Error; err != nil {
+		return err
+	}
+	if len(requestIDs) == 0 {
+		return nil
+	}
+
+	if err := p.db.Model(&models.WaitlistEntry{}).
+		Where("request_id IN ? AND status = ?", requestIDs, "waiting").
+		Update("status", "cancelled").Error; err != nil {
+		return err
+	}
+
+	for _, requestID := range requestIDs {
+		if err := p.markCancelled(ctx, requestID); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Removed %d requests for event %s from the waitlist", len(requestIDs), eventID)
+	return nil
+}
+
+// cancelForEvent cancels one booking of a cancelled event. It reports
+// false when the booking changed under it and has nothing left to cancel.
+func (p *CancelProcessor) cancelForEvent(ctx context.Context, booking models.Booking) (bool, error) {
+	key := "eventCancelled:" + booking.EventID + ":" + booking.ID
+
+	// the payment stage is still charging for this booking; once it sees
+	// the request cancelled it cancels the row, gives the seats back and
+	// refunds anything it took, so doing any of that here as well would
+	// release the seats twice
+	if booking.Status == "payment_pending" && booking.OrderID == "" {
+		if err := p.markCancelled(ctx, booking.RequestID); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	done := false
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", booking.ID).
+			First(&booking).Error; err != nil {
+			return err
+		}
+		if !cancellable[booking.Status] {
+			return nil
+		}
+
+		if err := tx.Model(&models.Booking{}).Where("id = ?", booking.ID).Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+
+		cancellation, _, _ := planCancellation(booking, models.KafkaCancelEvent{})
+		cancellation.CancelRequestID = key
+		cancellation.RestorePending = true
+
+		if booking.Status == "confirmed" {
+			refund := models.Refund{
+				BookingID: booking.ID,
+				RequestID: booking.RequestID,
+				UserID:    booking.UserID,
+				EventID:   booking.EventID,
+				Percent:   100,
+				Amount:    booking.Price,
+				Status:    "pending",
+			}
+			if err := recordRefund(tx, refund); err != nil {
+				return err
+			}
+			cancellation.Refund = refund.Amount
+			cancellation.RefundPercent = refund.Percent
+			log.Printf("Recorded full refund of %.2f for booking %s", refund.Amount, booking.ID)
+		}
+
+		if err := tx.Create(&cancellation).Error; err != nil {
+			return err
+		}
+
+		if booking.Status == "confirmed" || booking.Status == "held" {
+			outbox, err := seatsUpdateOutbox(key, booking)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&outbox).Error; err != nil {
+				return err
+			}
+		}
+
+		done = true
+		return nil
+	})
+	if err != nil || !done {
+		return false, err
+	}
+
+	if booking.OrderID == "" {
+		if err := p.markCancelled(ctx, booking.RequestID); err != nil {
+			log.Printf("Failed to mark request %s cancelled: %v", booking.RequestID, err)
+		}
+	}
+
+	return true, nil
+}
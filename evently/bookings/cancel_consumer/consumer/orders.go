@@ -0,0 +1,85 @@
+package consumer
+
+import (
+	"cancel_consumer/models"
+	"context"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cancelOrderAtDB cancels every booking of a multi-event order that still
+// holds seats, refunding the paid ones under each event's own policy. The
+// seats to give back come from the rows rather than the cancel message.
+func (p *CancelProcessor) cancelOrderAtDB(ctx context.Context, orderID, userID string, key []byte) error {
+	var bookings []models.Booking
+
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND user_id = ? AND status IN ?", orderID, userID, []string{"confirmed", "payment_pending"}).
+			Find(&bookings).Error; err != nil {
+			return err
+		}
+
+		for _, booking := range bookings {
+			if err := tx.Model(&models.Booking{}).Where("id = ?", booking.ID).Update("status", "cancelled").Error; err != nil {
+				return err
+			}
+
+			cancellation, _, _ := planCancellation(booking, models.KafkaCancelEvent{})
+			cancellation.CancelRequestID = string(key) + ":" + booking.ID
+			cancellation.RestorePending = true
+
+			if booking.Status == "confirmed" {
+				refund := p.buildRefund(ctx, booking, booking.Price)
+				if err := recordRefund(tx, refund); err != nil {
+					return err
+				}
+				cancellation.Refund = refund.Amount
+				cancellation.RefundPercent = refund.Percent
+				log.Printf("Recorded refund of %.2f (%.0f%%) for booking %s", refund.Amount, refund.Percent, booking.ID)
+
+				// only paid bookings were taken out of Mongo
+				outbox, err := seatsUpdateOutbox(string(key)+":"+booking.ID, booking)
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(&outbox).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Create(&cancellation).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Error cancelling order %s: %v", orderID, err)
+		return err
+	}
+
+	// a redelivery finds nothing left to cancel but may still owe the seats
+	// of what an earlier one cancelled
+	var bookingIDs []string
+	if err := p.db.Model(&models.Booking{}).Where("order_id = ?", orderID).Pluck("id", &bookingIDs).Error; err != nil {
+		log.Printf("Error looking up bookings of order %s: %v", orderID, err)
+		return err
+	}
+	if err := p.restoreCancelledSeats(ctx, true, "booking_id IN ?", bookingIDs); err != nil {
+		log.Printf("Error restoring seats for order %s: %v", orderID, err)
+		return err
+	}
+
+	if len(bookings) == 0 {
+		log.Printf("Refusing to cancel order %s for user %s: nothing left to cancel", orderID, userID)
+		return nil
+	}
+
+	log.Printf("Cancelled %d bookings of order %s", len(bookings), orderID)
+	return nil
+}
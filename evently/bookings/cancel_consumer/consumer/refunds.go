@@ -0,0 +1,85 @@
+package consumer
+
+import (
+	"cancel_consumer/models"
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loadRefundPolicy reads the policy the events service caches under
+// refundPolicy:<eventId>. A missing key means the event has no policy.
+func (p *CancelProcessor) loadRefundPolicy(ctx context.Context, eventID string) *models.RefundPolicy {
+	val, err := p.redisPrice.Get(ctx, "refundPolicy:"+eventID).Result()
+	if err != nil {
+		return nil
+	}
+
+	var policy models.RefundPolicy
+	if err := json.Unmarshal([]byte(val), &policy); err != nil {
+		log.Printf("Invalid refund policy for event %s: %v", eventID, err)
+		return nil
+	}
+
+	return &policy
+}
+
+// buildRefund works out what is paid back for value, the part of the
+// booking's price being cancelled.
+func (p *CancelProcessor) buildRefund(ctx context.Context, booking models.Booking, value float64) models.Refund {
+	percent := refundPercent(p.loadRefundPolicy(ctx, booking.EventID), time.Now())
+
+	return models.Refund{
+		BookingID: booking.ID,
+		RequestID: booking.RequestID,
+		UserID:    booking.UserID,
+		EventID:   booking.EventID,
+		Percent:   percent,
+		Amount:    math.Round(value*percent) / 100,
+		Status:    "pending",
+	}
+}
+
+// refundPercent applies the rule with the largest DaysBefore that the
+// cancellation still satisfies. Events without a policy are refunded in
+// full, and nothing is refunded once the event has started.
+func refundPercent(policy *models.RefundPolicy, now time.Time) float64 {
+	if policy == nil {
+		return 100
+	}
+
+	if !now.Before(policy.EventDate) {
+		return 0
+	}
+
+	daysLeft := int64(policy.EventDate.Sub(now).Hours() / 24)
+
+	best := int64(-1)
+	percent := 0.0
+	for _, rule := range policy.Rules {
+		if daysLeft >= rule.DaysBefore && rule.DaysBefore > best {
+			best = rule.DaysBefore
+			percent = rule.Percent
+		}
+	}
+
+	return percent
+}
+
+// recordRefund adds refund to the booking's refund row, so a booking
+// cancelled in several goes is paid back the sum of its cancellations.
+func recordRefund(tx *gorm.DB, refund models.Refund) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "booking_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"amount":     gorm.Expr("refunds.amount + excluded.amount"),
+			"percent":    gorm.Expr("excluded.percent"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&refund).Error
+}
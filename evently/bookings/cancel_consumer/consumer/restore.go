@@ -0,0 +1,95 @@
+package consumer
+
+import (
+	"cancel_consumer/models"
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// restoreMarkerTTL only has to outlive the gap between restoring a
+// cancellation's seats and clearing its RestorePending flag.
+const restoreMarkerTTL = 24 * time.Hour
+
+// restoreSeatsScript gives seats back to seatsLeft:<eventId>, its tier's
+// counter and seatsFree:<eventId> once per cancellation, guarded by the
+// marker in KEYS[1]. It returns 0 when the marker says it already ran.
+var restoreSeatsScript = redis.NewScript(`
+if not redis.call("SET", KEYS[1], "1", "NX", "EX", ARGV[1]) then
+    return 0
+end
+redis.call("INCRBY", KEYS[2], ARGV[2])
+if KEYS[4] then
+    redis.call("INCRBY", KEYS[4], ARGV[2])
+end
+if #ARGV > 2 then
+    redis.call("SADD", KEYS[3], unpack(ARGV, 3))
+end
+return 1
+`)
+
+// restoreCancelledSeats gives back the seats of every cancellation matching
+// the query that is still waiting for them, and with promote set offers
+// them to the waitlist. It runs after the cancellation is committed and on
+// every retry, so a Redis failure after the commit is picked up again even
+// though the cancellation itself is already applied.
+func (p *CancelProcessor) restoreCancelledSeats(ctx context.Context, promote bool, query string, args ...interface{}) error {
+	var pending []models.BookingCancellation
+	if err := p.db.Where("restore_pending = ?", true).Where(query, args...).Find(&pending).Error; err != nil {
+		return err
+	}
+
+	for _, cancellation := range pending {
+		restored, err := p.restoreSeats(ctx, cancellation)
+		if err != nil {
+			return err
+		}
+
+		if restored && promote {
+			p.promoteWaitlist(ctx, cancellation.EventID, cancellation.Tier, cancellation.Seats)
+		}
+
+		if err := p.db.Model(&models.BookingCancellation{}).
+			Where("id = ?", cancellation.ID).
+			Update("restore_pending", false).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreSeats gives a cancellation's seats back to seatsLeft:<eventId>
+// and its tier's counter, and its named seats back to seatsFree:<eventId>.
+// It reports false when an earlier attempt already did.
+func (p *CancelProcessor) restoreSeats(ctx context.Context, cancellation models.BookingCancellation) (bool, error) {
+	keys := []string{
+		"seatsRestored:" + cancellation.ID,
+		"seatsLeft:" + cancellation.EventID,
+		"seatsFree:" + cancellation.EventID,
+	}
+	if cancellation.Tier != "" {
+		keys = append(keys, "seatsLeft:"+cancellation.EventID+":"+cancellation.Tier)
+	}
+
+	args := []interface{}{int(restoreMarkerTTL.Seconds()), cancellation.Seats}
+	if cancellation.SeatIDs != "" {
+		for _, id := range strings.Split(cancellation.SeatIDs, ",") {
+			args = append(args, id)
+		}
+	}
+
+	n, err := restoreSeatsScript.Run(ctx, p.redisSeats, keys, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	log.Printf("Restored %d seats for eventId %s", cancellation.Seats, cancellation.EventID)
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"cancel_consumer/models"
+	"context"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const waitlistPromoteBatch = 20
+
+// promoteWaitlist queues waiting requests for an event's tier back onto the
+// booking topic through the outbox, oldest first, for as long as the
+// released seats cover them. It stops at the first request that does not
+// fit so the queue stays FIFO.
+func (p *CancelProcessor) promoteWaitlist(ctx context.Context, eventID, tier string, released int64) {
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		var entries []models.WaitlistEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("event_id = ? AND tier = ? AND status = ?", eventID, tier, "waiting").
+			Order("created_at ASC").
+			Limit(waitlistPromoteBatch).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.Seats > released {
+				break
+			}
+
+			if err := tx.Model(&models.WaitlistEntry{}).
+				Where("id = ?", entry.ID).
+				Update("status", "promoted").Error; err != nil {
+				return err
+			}
+
+			outbox := models.OutboxMessage{Topic: p.bookingsTopic, Key: entry.RequestID, Payload: entry.Payload}
+			if err := tx.Create(&outbox).Error; err != nil {
+				return err
+			}
+
+			released -= entry.Seats
+			log.Printf("Promoted waitlisted request %s for event %s", entry.RequestID, eventID)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Error promoting waitlist for event %s: %v", eventID, err)
+	}
+}
@@ -10,6 +10,8 @@ import(
     "github.com/segmentio/kafka-go/sasl/plain"
 )
 
+// Producer is shared by every goroutine of a consumer, so its writer has
+// no topic of its own and each message names the topic it goes to.
 type Producer struct {
     writer *kafka.Writer
 }
@@ -36,12 +38,11 @@ func NewProducer(broker string) *Producer {
 }
 
 func (p *Producer) Publish(topic string, key, value []byte) error {
-	p.writer.Topic = topic
-
 	log.Printf("Publishing message to Kafka: topic=%s, key=%s, value=%s\n", topic, string(key), string(value))
 
 	err := p.writer.WriteMessages(context.Background(),
 		kafka.Message{
+			Topic: topic,
 			Key:   key,
 			Value: value,
 		},
@@ -55,3 +56,22 @@ func (p *Producer) Publish(topic string, key, value []byte) error {
 
 	return err
 }
+
+// PublishWithHeaders is Publish for messages that carry Kafka headers, such
+// as dead letter metadata.
+func (p *Producer) PublishWithHeaders(topic string, key, value []byte, headers []kafka.Header) error {
+	err := p.writer.WriteMessages(context.Background(),
+		kafka.Message{
+			Topic:   topic,
+			Key:     key,
+			Value:   value,
+			Headers: headers,
+		},
+	)
+
+	if err != nil {
+		log.Println("Kafka publish error:", err)
+	}
+
+	return err
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"cancel_consumer/consumer"
 	"cancel_consumer/kafka"
+	"cancel_consumer/models"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/postgres"
@@ -18,10 +20,21 @@ import (
 func main() {
 	kafkaBrokers := mustGetEnv("KAFKA_BROKERS")
 	topic := mustGetEnv("TOPIC_CANCEL_REQUESTS")
+	eventTopic := mustGetEnv("TOPIC_EVENT_CANCELLATIONS")
+	bookingsTopic := mustGetEnv("TOPIC_BOOKINGS_REQUESTS")
 	group := mustGetEnv("CANCEL_CONSUMER_GROUP")
 
+	if len(os.Args) > 1 && os.Args[1] == "replay-dlq" {
+		replayDeadLetters(kafkaBrokers, []string{topic, eventTopic}, map[string]string{
+			topic:      group,
+			eventTopic: group + "-events",
+		}, os.Args[2:])
+		return
+	}
+
 	redisReq := newRedisClient(mustGetEnv("REDIS_REQUESTS_HOST"), mustGetEnv("REDIS_REQUESTS_PORT"), mustGetEnv("REDIS_REQUESTS_PASSWORD"))
 	redisSeats := newRedisClient(mustGetEnv("REDIS_SEATS_HOST"), mustGetEnv("REDIS_SEATS_PORT"), mustGetEnv("REDIS_SEATS_PASSWORD"))
+	redisPrice := newRedisClient(mustGetEnv("REDIS_PRICE_HOST"), mustGetEnv("REDIS_PRICE_PORT"), mustGetEnv("REDIS_PRICE_PASSWORD"))
 
 	dbHost := mustGetEnv("POSTGRES_BOOKINGS_HOST")
 	dbPort := mustGetEnv("POSTGRES_BOOKINGS_PORT")
@@ -46,10 +59,14 @@ func main() {
 		log.Fatal("Failed to connect to bookings database:", err)
 	}
 
+	if err := db.AutoMigrate(&models.Refund{}, &models.BookingCancellation{}, &models.OutboxMessage{}); err != nil {
+		log.Fatal("Failed to migrate cancel consumer tables:", err)
+	}
+
 	producer := kafka.NewProducer(kafkaBrokers)
 
 	log.Println("Starting Cancel Consumer...")
-	consumer.StartCancelConsumer(kafkaBrokers, topic, group, redisReq, redisSeats, db, producer)
+	consumer.StartCancelConsumer(kafkaBrokers, topic, eventTopic, bookingsTopic, group, redisReq, redisSeats, redisPrice, db, producer)
 
 }
 
@@ -74,3 +91,40 @@ func mustGetEnv(key string) string {
 	}
 	return value
 }
+
+// replayDeadLetters is the replay-dlq admin command:
+//
+//	replay-dlq [topic] [count]
+//
+// It moves up to count dead-lettered messages (all of them by default) back
+// onto the topic, which must be one this consumer reads and defaults to the
+// first of them. groups maps each topic to the consumer group reading it.
+func replayDeadLetters(broker string, topics []string, groups map[string]string, args []string) {
+	topic := topics[0]
+	if len(args) > 0 {
+		if _, err := strconv.Atoi(args[0]); err != nil {
+			topic = args[0]
+			args = args[1:]
+		}
+	}
+	group, ok := groups[topic]
+	if !ok {
+		log.Fatalf("Unknown topic %q, expected one of %v", topic, topics)
+	}
+
+	max := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid message count %q", args[0])
+		}
+		max = n
+	}
+
+	producer := kafka.NewProducer(broker)
+	n, err := kafka.ReplayDeadLetters(context.Background(), broker, topic, group, producer, max)
+	if err != nil {
+		log.Fatalf("Replayed %d messages before failing: %v", n, err)
+	}
+	log.Printf("Replayed %d messages from %s", n, kafka.DeadLetterTopic(topic))
+}
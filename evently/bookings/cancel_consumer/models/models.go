@@ -5,26 +5,124 @@ import (
 )
 
 type Booking struct {
+	ID            string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RequestID     string     `gorm:"type:varchar(255);not null" json:"requestId"`
+	OrderID       string     `gorm:"type:varchar(255);index" json:"orderId,omitempty"`
+	UserID        string     `gorm:"type:varchar(255);not null" json:"userId"`
+	EventID       string     `gorm:"type:varchar(255);not null" json:"eventId"`
+	Tier          string     `gorm:"type:varchar(100);not null;default:''" json:"tier,omitempty"`
+	UnitPrice     float64    `gorm:"type:numeric;not null;default:0" json:"unitPrice"`
+	Price         float64    `gorm:"type:numeric;not null" json:"price"`
+	PromoCode     string     `gorm:"type:varchar(100)" json:"promoCode,omitempty"`
+	Discount      float64    `gorm:"type:numeric;not null;default:0" json:"discount"`
+	Seats         int64      `gorm:"not null" json:"seats"`
+	SeatIDs       string     `gorm:"type:text" json:"seatIds"`
+	Status        string     `gorm:"type:varchar(50);not null" json:"status"`
+	HoldExpiresAt *time.Time `gorm:"index" json:"holdExpiresAt,omitempty"`
+	PaymentRef    string     `gorm:"type:varchar(255)" json:"paymentRef,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+type WaitlistEntry struct {
 	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	RequestID string    `gorm:"type:varchar(255);not null" json:"requestId"`
+	RequestID string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"requestId"`
 	UserID    string    `gorm:"type:varchar(255);not null" json:"userId"`
-	EventID   string    `gorm:"type:varchar(255);not null" json:"eventId"`
-	Price     float64   `gorm:"type:numeric;not null" json:"price"`
+	EventID   string    `gorm:"type:varchar(255);not null;index" json:"eventId"`
+	Tier      string    `gorm:"type:varchar(100);not null;default:''" json:"tier,omitempty"`
 	Seats     int64     `gorm:"not null" json:"seats"`
+	Payload   string    `gorm:"type:text;not null" json:"-"`
 	Status    string    `gorm:"type:varchar(50);not null" json:"status"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 }
 
+// OutboxMessage is a Kafka message written in the same transaction as the
+// booking change it describes and published later by the outbox relay.
+type OutboxMessage struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Topic         string     `gorm:"type:varchar(255);not null" json:"topic"`
+	Key           string     `gorm:"type:varchar(255);not null" json:"key"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"lastError,omitempty"`
+	NextAttemptAt time.Time  `gorm:"index" json:"nextAttemptAt"`
+	PublishedAt   *time.Time `gorm:"index" json:"publishedAt,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// KafkaCancelEvent names the booking to cancel and who is asking. Seats or
+// SeatIDs cancel only part of the booking; without them all of it goes.
+// The event and tier to give seats back to are read from the stored booking.
 type KafkaCancelEvent struct {
-	BookingRequestId string `json:"booking_request_id"`
-	EventId   string `json:"event_id"`
-	BookingId string `json:"booking_id"`
-	Seats     int64  `json:"seats"`
+	BookingRequestId string   `json:"booking_request_id"`
+	BookingId        string   `json:"booking_id"`
+	UserID           string   `json:"user_id"`
+	Seats            int64    `json:"seats,omitempty"`
+	SeatIDs          []string `json:"seat_ids,omitempty"`
+}
+
+// KafkaEventCancellation is queued by the events service when an event is
+// cancelled, asking for all of its bookings to be cancelled and refunded.
+type KafkaEventCancellation struct {
+	EventID string `json:"event_id"`
+}
+
+// IdempotencyKey mirrors the bookings consumer's record of which user
+// submitted a request id.
+type IdempotencyKey struct {
+	UserID    string    `gorm:"type:varchar(255);not null" json:"userId"`
+	RequestID string    `gorm:"type:varchar(255);not null" json:"requestId"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
 }
 
 type KafkaUpdateEvent struct {
 	EventId   string `json:"event_id"`
+	Tier      string `json:"tier,omitempty"`
 	Seats     int64  `json:"seats"`
 	Operation string `json:"operation"`
 }
+
+type Refund struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	BookingID string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"bookingId"`
+	RequestID string    `gorm:"type:varchar(255);not null" json:"requestId"`
+	UserID    string    `gorm:"type:varchar(255);not null" json:"userId"`
+	EventID   string    `gorm:"type:varchar(255);not null;index" json:"eventId"`
+	Amount    float64   `gorm:"type:numeric;not null" json:"amount"`
+	Percent   float64   `gorm:"type:numeric;not null" json:"percent"`
+	Status    string    `gorm:"type:varchar(50);not null" json:"status"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+type RefundPolicy struct {
+	EventDate time.Time    `json:"event_date"`
+	Rules     []RefundRule `json:"rules"`
+}
+
+type RefundRule struct {
+	DaysBefore int64   `json:"days_before"`
+	Percent    float64 `json:"percent"`
+}
+
+// BookingCancellation is one cancellation applied to a booking, either of
+// everything left on it or of some of its seats, with the refund due for
+// just those seats. CancelRequestID is the cancel request that made it.
+// RestorePending stays set until the released seats are back in Redis, so a
+// retry that finds the cancellation already applied still restores them.
+type BookingCancellation struct {
+	ID              string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CancelRequestID string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"cancelRequestId"`
+	BookingID       string    `gorm:"type:varchar(255);not null;index" json:"bookingId"`
+	UserID          string    `gorm:"type:varchar(255);not null" json:"userId"`
+	EventID         string    `gorm:"type:varchar(255);not null" json:"eventId"`
+	Tier            string    `gorm:"type:varchar(100);not null;default:''" json:"tier,omitempty"`
+	Seats           int64     `gorm:"not null" json:"seats"`
+	SeatIDs         string    `gorm:"type:text" json:"seatIds,omitempty"`
+	RemainingSeats  int64     `gorm:"not null" json:"remainingSeats"`
+	Refund          float64   `gorm:"type:numeric;not null;default:0" json:"refund"`
+	RefundPercent   float64   `gorm:"type:numeric;not null;default:0" json:"refundPercent"`
+	RestorePending  bool      `gorm:"not null;default:false;index" json:"-"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
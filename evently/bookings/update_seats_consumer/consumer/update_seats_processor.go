@@ -18,6 +18,7 @@ import (
 
 type KafkaUpdateEvent struct {
 	EventId   string `json:"event_id"`
+	Tier      string `json:"tier,omitempty"`
 	Seats     int64  `json:"seats"`
 	Operation string `json:"operation"`
 }
@@ -27,7 +28,7 @@ func processUpdateSeatsMessage(ctx context.Context, key []byte, value []byte, re
 
 	if err := json.Unmarshal(value, &msg); err != nil {
 		log.Printf("Failed to parse update seats message: %v", err)
-		return err
+		return kafka.Permanent(err)
 	}
 
 	updatedSeatsKey := "updatedSeats:" + string(key)
@@ -48,22 +49,45 @@ func processUpdateSeatsMessage(ctx context.Context, key []byte, value []byte, re
 	collection := mongoClient.Database("eventsdb").Collection("events")
 	filter := bson.M{"_id": oid}
 
-	var update bson.M
+	var delta int64
 
 	if msg.Operation == "add" {
-		update = bson.M{"$inc": bson.M{"available_seats": msg.Seats}}
+		delta = msg.Seats
 	} else if msg.Operation == "subtract" {
-		update = bson.M{"$inc": bson.M{"available_seats": -msg.Seats}}
+		delta = -msg.Seats
 	} else {
 		return fmt.Errorf("Invalid operation type: %s for requestId %s", msg.Operation, string(key))
 	}
 
+	inc := bson.M{"available_seats": delta}
+
+	// a tiered booking also moves the matching tier's own pool
+	if msg.Tier != "" {
+		filter["tiers.name"] = msg.Tier
+		inc["tiers.$.available_seats"] = delta
+	}
+
+	update := bson.M{"$inc": inc}
+
 	res, err := collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Failed to update MongoDB: %v", err)
 		return err
 	}
 
+	if res.MatchedCount == 0 && msg.Tier != "" {
+		// the event may exist without this tier, which no retry can fix
+		n, err := collection.CountDocuments(ctx, bson.M{"_id": oid})
+		if err != nil {
+			log.Printf("Failed to look up event %s: %v", msg.EventId, err)
+			return err
+		}
+		if n > 0 {
+			log.Printf("Event %s has no tier %q for request %s", msg.EventId, msg.Tier, string(key))
+			return kafka.Permanent(fmt.Errorf("event %s has no tier %q", msg.EventId, msg.Tier))
+		}
+	}
+
 	if res.MatchedCount == 0 {
 		log.Printf("No event found with ID %s", msg.EventId)
 		return nil
@@ -34,6 +34,7 @@ func StartUpdateSeatsConsumer(
 
 	reader := kafka.NewReader(broker, topic, groupID)
 	defer reader.Close()
+	reader.EnableDeadLetter(producer, kafka.RetryPolicyFromEnv())
 
 	log.Printf("UpdateSeats Kafka consumer started: topic=%s, groupID=%s", topic, groupID)
 
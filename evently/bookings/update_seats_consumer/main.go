@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"update_seats_consumer/consumer"
@@ -19,6 +20,11 @@ func main() {
 	topic := mustGetEnv("UPDATE_SEATS_REQUESTS")
 	group := mustGetEnv("UPDATE_SEATS_CONSUMER_GROUP")
 
+	if len(os.Args) > 1 && os.Args[1] == "replay-dlq" {
+		replayDeadLetters(kafkaBrokers, topic, group, os.Args[2:])
+		return
+	}
+
 	redisUpdatedSeats := newRedisClient(mustGetEnv("REDIS_UPDATED_SEATS_HOST"), mustGetEnv("REDIS_UPDATED_SEATS_PORT"), mustGetEnv("REDIS_UPDATED_SEATS_PASSWORD"))
 
 	dbHost := mustGetEnv("DB_EVENTS_HOST")
@@ -83,4 +89,25 @@ func newRedisClient(host, port, password string) *redis.Client {
 	}
 	log.Println("Connected to Redis at", host+":"+port)
 	return rdb
-}
\ No newline at end of file
+}
+
+// replayDeadLetters is the replay-dlq admin command. It moves up to the
+// given number of dead-lettered messages (all of them by default) back onto
+// the consumer's topic.
+func replayDeadLetters(broker, topic, group string, args []string) {
+	max := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid message count %q", args[0])
+		}
+		max = n
+	}
+
+	producer := kafka.NewProducer(broker)
+	n, err := kafka.ReplayDeadLetters(context.Background(), broker, topic, group, producer, max)
+	if err != nil {
+		log.Fatalf("Replayed %d messages before failing: %v", n, err)
+	}
+	log.Printf("Replayed %d messages from %s", n, kafka.DeadLetterTopic(topic))
+}
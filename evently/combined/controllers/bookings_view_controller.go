@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"combined/models"
 	"combined/service"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -37,13 +39,15 @@ func (c *BookingsViewController) GetAllBookings(ctx *gin.Context) {
 	page, _ := strconv.ParseInt(ctx.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(ctx.DefaultQuery("limit", "10"), 10, 64)
 
-	bookings, err := c.bookingsViewService.GetAllBookings(page,limit)
+	cursor, cursorMode := ctx.GetQuery("cursor")
+
+	bookings, err := c.bookingsViewService.GetAllBookings(page, limit, cursor)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondListError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, bookings)
+	respondBookingsPage(ctx, bookings, cursorMode)
 }
 
 func (c *BookingsViewController) GetBookingsByEventID(ctx *gin.Context) {
@@ -58,14 +62,16 @@ func (c *BookingsViewController) GetBookingsByEventID(ctx *gin.Context) {
 	page, _ := strconv.ParseInt(ctx.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(ctx.DefaultQuery("limit", "10"), 10, 64)
 
-	bookings, err := c.bookingsViewService.GetBookingsByEventID(eventID, limit, page, status)
+	cursor, cursorMode := ctx.GetQuery("cursor")
+
+	bookings, err := c.bookingsViewService.GetBookingsByEventID(eventID, limit, page, status, cursor)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondListError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, bookings)
+	respondBookingsPage(ctx, bookings, cursorMode)
 }
 
 func (c *BookingsViewController) GetBookingsByUserID(ctx *gin.Context) {
@@ -80,14 +86,37 @@ func (c *BookingsViewController) GetBookingsByUserID(ctx *gin.Context) {
 	page, _ := strconv.ParseInt(ctx.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(ctx.DefaultQuery("limit", "10"), 10, 64)
 
-	bookings, err := c.bookingsViewService.GetBookingsByUserID(userID, limit, page, status)
+	cursor, cursorMode := ctx.GetQuery("cursor")
+
+	bookings, err := c.bookingsViewService.GetBookingsByUserID(userID, limit, page, status, cursor)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondListError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, bookings)
+	respondBookingsPage(ctx, bookings, cursorMode)
+}
+
+// respondBookingsPage answers page/limit callers with the bare list they
+// always got. Passing a cursor, even an empty one for the first page,
+// switches to the paged form with next_cursor and has_more.
+func respondBookingsPage(ctx *gin.Context, page *models.BookingsPage, cursorMode bool) {
+	if cursorMode {
+		ctx.JSON(http.StatusOK, page)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page.Bookings)
+}
+
+func respondListError(ctx *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidCursor) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 func (c *BookingsViewController) GetBookingByRequestID(ctx *gin.Context) {
@@ -107,6 +136,23 @@ func (c *BookingsViewController) GetBookingByRequestID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, booking)
 }
 
+func (c *BookingsViewController) GetOrder(ctx *gin.Context) {
+	orderID := ctx.Param("order_id")
+
+	if orderID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "order_id is required"})
+		return
+	}
+
+	order, err := c.bookingsViewService.GetOrder(orderID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
 func (c *BookingsViewController) GetTotalBookings(ctx *gin.Context) {
 
 	bookingsCount, err := c.bookingsViewService.GetTotalBookings()
@@ -136,3 +182,34 @@ func (c *BookingsViewController) GetDailyBookingStats(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, stats)
 }
+
+func (c *BookingsViewController) GetRefundReport(ctx *gin.Context) {
+	eventID := ctx.Query("event_id")
+	startDate := ctx.Query("start_date")
+	endDate := ctx.Query("end_date")
+
+	report, err := c.bookingsViewService.GetRefundReport(eventID, startDate, endDate)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func (c *BookingsViewController) GetWaitlistPosition(ctx *gin.Context) {
+	reqID := ctx.Param("request_id")
+
+	if reqID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "reqID is required"})
+		return
+	}
+
+	position, err := c.bookingsViewService.GetWaitlistPosition(reqID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, position)
+}
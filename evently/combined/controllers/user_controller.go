@@ -39,6 +39,14 @@ func (uc *UserController) Register(c *gin.Context) {
 		return
 	}
 
+	// organizers can create events, so they need a secret of their own
+	organizerSecret, ok := os.LookupEnv("ORGANIZER_SECRET")
+	if strings.ToLower(user.Role) == "organizer" && (!ok || organizerSecret == "" || c.Query("organizer_secret") != organizerSecret) {
+		log.Println("Invalid organizer secret attempt for email:", user.Email)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organizer secret: you are not authorized to register as an organizer"})
+		return
+	}
+
 	if err := uc.service.Register(&user); err != nil {
 		log.Println("Error registering user:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
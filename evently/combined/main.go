@@ -58,6 +58,8 @@ func main() {
 		api.GET("/bookings/:id", bookingController.GetBookingByID)
 		api.GET("/bookings/user/:user_id", bookingController.GetBookingsByUserID)
 		api.GET("/bookings/request/:request_id", bookingController.GetBookingByRequestID)
+		api.GET("/bookings/orders/:order_id", bookingController.GetOrder)
+		api.GET("/bookings/waitlist/:request_id", bookingController.GetWaitlistPosition)
 
 		admin := api.Group("/bookings")
 		admin.Use(auth.AdminOnly())
@@ -66,6 +68,7 @@ func main() {
 			admin.GET("/event/:event_id", bookingController.GetBookingsByEventID)
 			admin.GET("/analytics/total-bookings", bookingController.GetTotalBookings)
 			admin.GET("/analytics/dailyStats", bookingController.GetDailyBookingStats)
+			admin.GET("/analytics/refunds", bookingController.GetRefundReport)
 		}
 	}
 
@@ -5,17 +5,104 @@ import (
 )
 
 type Booking struct {
+	ID            string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RequestID     string     `gorm:"type:varchar(255);not null" json:"requestId"`
+	OrderID       string     `gorm:"type:varchar(255);index" json:"orderId,omitempty"`
+	UserID        string     `gorm:"type:varchar(255);not null" json:"userId"`
+	EventID       string     `gorm:"type:varchar(255);not null" json:"eventId"`
+	Tier          string     `gorm:"type:varchar(100);not null;default:''" json:"tier,omitempty"`
+	UnitPrice     float64    `gorm:"type:numeric;not null;default:0" json:"unitPrice"`
+	Price         float64    `gorm:"type:numeric;not null" json:"price"`
+	PromoCode     string     `gorm:"type:varchar(100)" json:"promoCode,omitempty"`
+	Discount      float64    `gorm:"type:numeric;not null;default:0" json:"discount"`
+	Seats         int64      `gorm:"not null" json:"seats"`
+	SeatIDs       string     `gorm:"type:text" json:"seatIds"`
+	Status        string     `gorm:"type:varchar(50);not null" json:"status"`
+	HoldExpiresAt *time.Time `gorm:"index" json:"holdExpiresAt,omitempty"`
+	PaymentRef    string     `gorm:"type:varchar(255)" json:"paymentRef,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+type Refund struct {
 	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	BookingID string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"bookingId"`
 	RequestID string    `gorm:"type:varchar(255);not null" json:"requestId"`
 	UserID    string    `gorm:"type:varchar(255);not null" json:"userId"`
-	EventID   string    `gorm:"type:varchar(255);not null" json:"eventId"`
-	Price     float64   `gorm:"type:numeric;not null" json:"price"`
+	EventID   string    `gorm:"type:varchar(255);not null;index" json:"eventId"`
+	Amount    float64   `gorm:"type:numeric;not null" json:"amount"`
+	Percent   float64   `gorm:"type:numeric;not null" json:"percent"`
+	Status    string    `gorm:"type:varchar(50);not null" json:"status"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+type WaitlistEntry struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RequestID string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"requestId"`
+	UserID    string    `gorm:"type:varchar(255);not null" json:"userId"`
+	EventID   string    `gorm:"type:varchar(255);not null;index" json:"eventId"`
+	Tier      string    `gorm:"type:varchar(100);not null;default:''" json:"tier,omitempty"`
 	Seats     int64     `gorm:"not null" json:"seats"`
+	Payload   string    `gorm:"type:text;not null" json:"-"`
 	Status    string    `gorm:"type:varchar(50);not null" json:"status"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 }
 
+type WaitlistPosition struct {
+	RequestID string `json:"request_id"`
+	EventID   string `json:"event_id"`
+	Tier      string `json:"tier,omitempty"`
+	Seats     int64  `json:"seats"`
+	Status    string `json:"status"`
+	Position  int64  `json:"position,omitempty"`
+}
+
+// BookingCancellation is one cancellation applied to a booking, of all of
+// its remaining seats or just some of them, with the refund for those seats.
+type BookingCancellation struct {
+	ID              string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CancelRequestID string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"cancelRequestId"`
+	BookingID       string    `gorm:"type:varchar(255);not null;index" json:"bookingId"`
+	UserID          string    `gorm:"type:varchar(255);not null" json:"userId"`
+	EventID         string    `gorm:"type:varchar(255);not null" json:"eventId"`
+	Tier            string    `gorm:"type:varchar(100);not null;default:''" json:"tier,omitempty"`
+	Seats           int64     `gorm:"not null" json:"seats"`
+	SeatIDs         string    `gorm:"type:text" json:"seatIds,omitempty"`
+	RemainingSeats  int64     `gorm:"not null" json:"remainingSeats"`
+	Refund          float64   `gorm:"type:numeric;not null;default:0" json:"refund"`
+	RefundPercent   float64   `gorm:"type:numeric;not null;default:0" json:"refundPercent"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// BookingChange is one entry of a booking's history: when it was booked
+// and every cancellation since, with the seats left after each.
+type BookingChange struct {
+	Action         string    `json:"action"`
+	Seats          int64     `json:"seats"`
+	SeatIDs        string    `json:"seat_ids,omitempty"`
+	RemainingSeats int64     `json:"remaining_seats"`
+	Refund         float64   `json:"refund,omitempty"`
+	At             time.Time `json:"at"`
+}
+
+type BookingDetail struct {
+	Booking
+	Refund  *Refund         `json:"refund,omitempty"`
+	History []BookingChange `json:"history"`
+}
+
+// Order groups the bookings of a multi-event order. Status is the status
+// its bookings share, or "mixed" once some of them were cancelled on their own.
+type Order struct {
+	OrderID  string    `json:"order_id"`
+	UserID   string    `json:"user_id"`
+	Status   string    `json:"status"`
+	Total    float64   `json:"total"`
+	Bookings []Booking `json:"bookings"`
+}
+
 type BookingsCount struct {
 	Total     int64 `json:"total"`
 	Confirmed int64 `json:"confirmed"`
@@ -28,3 +115,30 @@ type DailyBookingStats struct {
 	ConfirmedCount int64  `json:"confirmed_count"`
 	CancelledCount int64  `json:"cancelled_count"`
 }
+
+type RefundReportRow struct {
+	EventID     string  `json:"event_id"`
+	RefundCount int64   `json:"refund_count"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+type RefundReport struct {
+	RefundCount int64             `json:"refund_count"`
+	TotalAmount float64           `json:"total_amount"`
+	Events      []RefundReportRow `json:"events"`
+}
+
+// Cursor is where a keyset-paginated listing carries on from: the
+// created_at of the last booking returned and its id to break ties.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// BookingsPage is a page of a bookings listing and the cursor to pass to
+// get the next one.
+type BookingsPage struct {
+	Bookings   []Booking `json:"bookings"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+}
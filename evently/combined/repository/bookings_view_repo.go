@@ -8,13 +8,19 @@ import (
 )
 
 type BookingsViewRepository interface {
-	GetAllBookings(page, limit int64) ([]models.Booking, error)
+	GetAllBookings(page, limit int64, after *models.Cursor) ([]models.Booking, error)
 	GetByID(id string) (*models.Booking, error)
-	GetByEventID(eventID string, limit, page int64, status string) ([]models.Booking, error)
-	GetByUserID(userID string, limit, page int64, status string) ([]models.Booking, error)
+	GetByEventID(eventID string, limit, page int64, status string, after *models.Cursor) ([]models.Booking, error)
+	GetByUserID(userID string, limit, page int64, status string, after *models.Cursor) ([]models.Booking, error)
 	GetBookingByRequestID(reqID string) (*models.Booking, error)
+	GetByOrderID(orderID string) ([]models.Booking, error)
 	GetTotalBookings() (*models.BookingsCount, error)
 	GetDailyBookingStats(eventID, startDate, endDate string) ([]models.DailyBookingStats, error)
+	GetRefundByBookingID(bookingID string) (*models.Refund, error)
+	GetCancellations(bookingID string) ([]models.BookingCancellation, error)
+	GetWaitlistEntry(reqID string) (*models.WaitlistEntry, error)
+	CountWaitlistAhead(entry *models.WaitlistEntry) (int64, error)
+	GetRefundReport(eventID, startDate, endDate string) ([]models.RefundReportRow, error)
 }
 
 type bookingsViewRepository struct {
@@ -25,18 +31,28 @@ func NewBookingsViewRepository(db *gorm.DB) BookingsViewRepository {
 	return &bookingsViewRepository{db}
 }
 
-func (r *bookingsViewRepository) GetAllBookings(page, limit int64) ([]models.Booking, error) {
-    var bookings []models.Booking
+// paginate orders a bookings listing newest first and picks the page,
+// either after the cursor or, without one, by skipping earlier pages. It
+// loads one booking past limit so the caller can tell if more follow.
+func paginate(query *gorm.DB, limit, page int64, after *models.Cursor) *gorm.DB {
+	query = query.Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(int(limit + 1))
+	}
 
-    offset := int((page - 1) * limit)
+	if after != nil {
+		return query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	return query.Offset(int((page - 1) * limit))
+}
 
-    if err := r.db.
-        Limit(int(limit)).
-        Offset(offset).
-        Find(&bookings).Error; err != nil {
-        return nil, err
-    }
-    return bookings, nil
+func (r *bookingsViewRepository) GetAllBookings(page, limit int64, after *models.Cursor) ([]models.Booking, error) {
+	var bookings []models.Booking
+
+	if err := paginate(r.db, limit, page, after).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
 }
 
 
@@ -53,41 +69,31 @@ func (r *bookingsViewRepository) GetByID(id string) (*models.Booking, error) {
 	return &booking, nil
 }
 
-func (r *bookingsViewRepository) GetByEventID(eventID string, limit, page int64, status string) ([]models.Booking, error) {
+func (r *bookingsViewRepository) GetByEventID(eventID string, limit, page int64, status string, after *models.Cursor) ([]models.Booking, error) {
 	var bookings []models.Booking
 
-	offset := int((page - 1) * limit)
-
 	query := r.db.Where("event_id = ?", eventID)
 
 	if status != "all" {
 		query = query.Where("status = ?", status)
 	}
 
-	if err := query.
-		Limit(int(limit)).
-		Offset(offset).
-		Find(&bookings).Error; err != nil {
+	if err := paginate(query, limit, page, after).Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
 }
 
-func (r *bookingsViewRepository) GetByUserID(userID string, limit, page int64, status string) ([]models.Booking, error) {
+func (r *bookingsViewRepository) GetByUserID(userID string, limit, page int64, status string, after *models.Cursor) ([]models.Booking, error) {
 	var bookings []models.Booking
 
-	offset := int((page - 1) * limit)
-
 	query := r.db.Where("user_id = ?", userID)
 
 	if status != "all" {
 		query = query.Where("status = ?", status)
 	}
 
-	if err := query.
-		Limit(int(limit)).
-		Offset(offset).
-		Find(&bookings).Error; err != nil {
+	if err := paginate(query, limit, page, after).Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 
@@ -104,6 +110,15 @@ func (r *bookingsViewRepository) GetBookingByRequestID(reqID string) (*models.Bo
 	return &booking, nil
 }
 
+func (r *bookingsViewRepository) GetByOrderID(orderID string) ([]models.Booking, error) {
+	var bookings []models.Booking
+
+	if err := r.db.Where("order_id = ?", orderID).Order("created_at ASC").Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
 func (r *bookingsViewRepository) GetTotalBookings() (*models.BookingsCount, error) {
 	var count models.BookingsCount
 
@@ -139,3 +154,73 @@ func (r *bookingsViewRepository) GetDailyBookingStats(eventID, startDate, endDat
 	return results, err
 }
 
+func (r *bookingsViewRepository) GetRefundByBookingID(bookingID string) (*models.Refund, error) {
+	var refund models.Refund
+
+	err := r.db.Where("booking_id = ?", bookingID).First(&refund).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+func (r *bookingsViewRepository) GetCancellations(bookingID string) ([]models.BookingCancellation, error) {
+	var cancellations []models.BookingCancellation
+
+	if err := r.db.Where("booking_id = ?", bookingID).Order("created_at ASC").Find(&cancellations).Error; err != nil {
+		return nil, err
+	}
+	return cancellations, nil
+}
+
+func (r *bookingsViewRepository) GetRefundReport(eventID, startDate, endDate string) ([]models.RefundReportRow, error) {
+	var rows []models.RefundReportRow
+
+	query := r.db.Model(&models.Refund{}).
+		Select("event_id, COUNT(*) as refund_count, COALESCE(SUM(amount), 0) as total_amount").
+		Group("event_id").
+		Order("total_amount DESC")
+
+	if eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+
+	if startDate != "" {
+		query = query.Where("created_at >= ?", startDate)
+	}
+
+	if endDate != "" {
+		query = query.Where("created_at <= ?", endDate)
+	}
+
+	err := query.Scan(&rows).Error
+	return rows, err
+}
+
+func (r *bookingsViewRepository) GetWaitlistEntry(reqID string) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+
+	err := r.db.Where("request_id = ?", reqID).First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("request is not on a waitlist")
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *bookingsViewRepository) CountWaitlistAhead(entry *models.WaitlistEntry) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.WaitlistEntry{}).
+		Where("event_id = ? AND tier = ? AND status = ? AND created_at < ?", entry.EventID, entry.Tier, "waiting", entry.CreatedAt).
+		Count(&count).Error
+
+	return count, err
+}
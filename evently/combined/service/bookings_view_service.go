@@ -7,13 +7,16 @@ import (
 )
 
 type BookingsViewService interface {
-	GetAllBookings(page,limit int64) ([]models.Booking, error)
-	GetBookingByID(id string) (*models.Booking, error)
-	GetBookingsByEventID(eventID string, limit, page int64, status string) ([]models.Booking, error)
+	GetAllBookings(page, limit int64, cursor string) (*models.BookingsPage, error)
+	GetBookingByID(id string) (*models.BookingDetail, error)
+	GetBookingsByEventID(eventID string, limit, page int64, status, cursor string) (*models.BookingsPage, error)
 	GetBookingByRequestID(reqID string) (*models.Booking, error)
-	GetBookingsByUserID(userID string, limit, page int64, status string) ([]models.Booking, error)
+	GetOrder(orderID string) (*models.Order, error)
+	GetBookingsByUserID(userID string, limit, page int64, status, cursor string) (*models.BookingsPage, error)
 	GetTotalBookings() (*models.BookingsCount, error) 
 	GetDailyBookingStats(eventID, startDate, endDate string) ([]models.DailyBookingStats, error)
+	GetRefundReport(eventID, startDate, endDate string) (*models.RefundReport, error)
+	GetWaitlistPosition(reqID string) (*models.WaitlistPosition, error)
 }
 
 type bookingsViewService struct {
@@ -24,12 +27,14 @@ func NewBookingsViewService(repo repository.BookingsViewRepository) BookingsView
 	return &bookingsViewService{repo: repo}
 }
 
-func (s *bookingsViewService) GetAllBookings(page, limit int64) ([]models.Booking, error) {
-	return s.repo.GetAllBookings(page, limit)
+func (s *bookingsViewService) GetAllBookings(page, limit int64, cursor string) (*models.BookingsPage, error) {
+	return bookingsPage(limit, cursor, func(after *models.Cursor) ([]models.Booking, error) {
+		return s.repo.GetAllBookings(page, limit, after)
+	})
 }
 
 
-func (s *bookingsViewService) GetBookingByID(id string) (*models.Booking, error) {
+func (s *bookingsViewService) GetBookingByID(id string) (*models.BookingDetail, error) {
 	booking, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -39,19 +44,89 @@ func (s *bookingsViewService) GetBookingByID(id string) (*models.Booking, error)
 		return nil, errors.New("booking not found")
 	}
 
-	return booking, nil
+	refund, err := s.repo.GetRefundByBookingID(booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cancellations, err := s.repo.GetCancellations(booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BookingDetail{Booking: *booking, Refund: refund, History: bookingHistory(booking, cancellations)}, nil
+}
+
+// bookingHistory rebuilds what happened to a booking from its cancellations.
+// The seats originally booked are what the first cancellation started from.
+func bookingHistory(booking *models.Booking, cancellations []models.BookingCancellation) []models.BookingChange {
+	booked := booking.Seats
+	if len(cancellations) > 0 {
+		booked = cancellations[0].Seats + cancellations[0].RemainingSeats
+	}
+
+	history := []models.BookingChange{{
+		Action:         "booked",
+		Seats:          booked,
+		RemainingSeats: booked,
+		At:             booking.CreatedAt,
+	}}
+
+	for _, c := range cancellations {
+		history = append(history, models.BookingChange{
+			Action:         "cancelled",
+			Seats:          c.Seats,
+			SeatIDs:        c.SeatIDs,
+			RemainingSeats: c.RemainingSeats,
+			Refund:         c.Refund,
+			At:             c.CreatedAt,
+		})
+	}
+
+	return history
 }
 
 func (s *bookingsViewService) GetBookingByRequestID(reqID string) (*models.Booking, error) {
 	return s.repo.GetBookingByRequestID(reqID)
 }
 
-func (s *bookingsViewService) GetBookingsByEventID(eventID string, limit, page int64, status string) ([]models.Booking, error) {
-	return s.repo.GetByEventID(eventID, limit, page, status)
+func (s *bookingsViewService) GetOrder(orderID string) (*models.Order, error) {
+	bookings, err := s.repo.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bookings) == 0 {
+		return nil, errors.New("order not found")
+	}
+
+	order := &models.Order{
+		OrderID:  orderID,
+		UserID:   bookings[0].UserID,
+		Status:   bookings[0].Status,
+		Bookings: bookings,
+	}
+
+	for _, b := range bookings {
+		order.Total += b.Price
+		if b.Status != order.Status {
+			order.Status = "mixed"
+		}
+	}
+
+	return order, nil
+}
+
+func (s *bookingsViewService) GetBookingsByEventID(eventID string, limit, page int64, status, cursor string) (*models.BookingsPage, error) {
+	return bookingsPage(limit, cursor, func(after *models.Cursor) ([]models.Booking, error) {
+		return s.repo.GetByEventID(eventID, limit, page, status, after)
+	})
 }
 
-func (s *bookingsViewService) GetBookingsByUserID(userID string, limit, page int64, status string) ([]models.Booking, error) {
-	return s.repo.GetByUserID(userID, limit, page, status)
+func (s *bookingsViewService) GetBookingsByUserID(userID string, limit, page int64, status, cursor string) (*models.BookingsPage, error) {
+	return bookingsPage(limit, cursor, func(after *models.Cursor) ([]models.Booking, error) {
+		return s.repo.GetByUserID(userID, limit, page, status, after)
+	})
 }
 
 func (s *bookingsViewService) GetTotalBookings() (*models.BookingsCount, error) {
@@ -62,3 +137,44 @@ func (s *bookingsViewService) GetDailyBookingStats(eventID, startDate, endDate s
 	return s.repo.GetDailyBookingStats(eventID, startDate, endDate)
 }
 
+func (s *bookingsViewService) GetRefundReport(eventID, startDate, endDate string) (*models.RefundReport, error) {
+	rows, err := s.repo.GetRefundReport(eventID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RefundReport{Events: rows}
+	for _, row := range rows {
+		report.RefundCount += row.RefundCount
+		report.TotalAmount += row.TotalAmount
+	}
+
+	return report, nil
+}
+
+func (s *bookingsViewService) GetWaitlistPosition(reqID string) (*models.WaitlistPosition, error) {
+	entry, err := s.repo.GetWaitlistEntry(reqID)
+	if err != nil {
+		return nil, err
+	}
+
+	position := &models.WaitlistPosition{
+		RequestID: entry.RequestID,
+		EventID:   entry.EventID,
+		Tier:      entry.Tier,
+		Seats:     entry.Seats,
+		Status:    entry.Status,
+	}
+
+	if entry.Status != "waiting" {
+		return position, nil
+	}
+
+	ahead, err := s.repo.CountWaitlistAhead(entry)
+	if err != nil {
+		return nil, err
+	}
+	position.Position = ahead + 1
+
+	return position, nil
+}
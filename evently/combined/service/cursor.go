@@ -0,0 +1,60 @@
+package service
+
+import (
+	"combined/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor makes the opaque next_cursor handed to clients.
+func encodeCursor(cursor models.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor from a client. An empty one starts from the
+// first page.
+func decodeCursor(value string) (*models.Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor models.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// bookingsPage loads one page of a bookings listing through list, which
+// returns a booking past limit when there is a next page.
+func bookingsPage(limit int64, cursor string, list func(after *models.Cursor) ([]models.Booking, error)) (*models.BookingsPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := list(after)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.BookingsPage{Bookings: bookings}
+	if limit > 0 && int64(len(bookings)) > limit {
+		page.Bookings = bookings[:limit]
+		last := page.Bookings[limit-1]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	return page, nil
+}
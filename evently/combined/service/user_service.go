@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"os"
+	"strings"
 	"time"
 	"combined/models"
 	"combined/repository"
@@ -31,6 +32,15 @@ func (s *userService) Register(user *models.User) error {
 		return errors.New("email already registered")
 	}
 
+	switch strings.ToLower(user.Role) {
+	case "", "user":
+		user.Role = "user"
+	case "admin", "organizer":
+		user.Role = strings.ToLower(user.Role)
+	default:
+		return errors.New("role must be user, organizer or admin")
+	}
+
 	hashed, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -56,6 +66,8 @@ func (s *userService) Login(email, password string) (string, error) {
 		secret = "supersecret"
 	}
 
+	// the gateway passes role and user_id on to the services, where an
+	// organizer role limits event management to the user's own events
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
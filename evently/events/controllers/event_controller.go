@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"errors"
+	"events/auth"
 	"events/models"
 	"events/service"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,9 +31,9 @@ func (ec *EventController) CreateEvent(c *gin.Context) {
 		return
 	}
 
-	createdEvent, err := ec.service.CreateEvent(ctx, &event)
+	createdEvent, err := ec.service.CreateEvent(ctx, &event, auth.OrganizerScope(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondManageError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -54,17 +58,39 @@ func (ec *EventController) GetEventByID(c *gin.Context) {
 	c.JSON(http.StatusOK, event)
 }
 
+func (ec *EventController) GetSeatMap(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event id is missing"})
+		return
+	}
+
+	seats, err := ec.service.GetSeatMap(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event_id": id, "seats": seats})
+}
+
 func (ec *EventController) GetAllEvents(c *gin.Context) {
 	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
 
-	events, err := ec.service.GetAllEvents(page, limit)
+	events, info, err := ec.service.GetAllEvents(page, limit, c.Query("cursor"))
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"events": events})
+	c.JSON(http.StatusOK, gin.H{"events": events, "next_cursor": info.NextCursor, "has_more": info.HasMore})
 }
 
 func (ec *EventController) GetAllUpcomingEvents(c *gin.Context) {
@@ -73,13 +99,90 @@ func (ec *EventController) GetAllUpcomingEvents(c *gin.Context) {
 	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
 
-	events, err := ec.service.GetAllUpcomingEvents(ctx, page, limit)
+	filter := models.UpcomingFilter{Category: c.Query("category"), Tag: c.Query("tag")}
+
+	events, info, err := ec.service.GetAllUpcomingEvents(ctx, page, limit, c.Query("cursor"), filter)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch upcoming events"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"upcoming_events": events})
+	c.JSON(http.StatusOK, gin.H{"upcoming_events": events, "next_cursor": info.NextCursor, "has_more": info.HasMore})
+}
+
+// SearchEvents serves GET /events/search. Dates are RFC3339 or plain
+// YYYY-MM-DD, and to is exclusive.
+func (ec *EventController) SearchEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
+
+	search := models.EventSearch{
+		Query:     strings.TrimSpace(c.Query("q")),
+		Venue:     c.Query("venue"),
+		Available: c.Query("available") == "true",
+		Sort:      c.Query("sort"),
+		Page:      page,
+		Limit:     limit,
+	}
+
+	var err error
+	if search.From, err = parseSearchDate(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date"})
+		return
+	}
+	if search.To, err = parseSearchDate(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date"})
+		return
+	}
+	if search.MinPrice, err = parseSearchPrice(c.Query("min_price")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_price"})
+		return
+	}
+	if search.MaxPrice, err = parseSearchPrice(c.Query("max_price")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid max_price"})
+		return
+	}
+
+	result, err := ec.service.SearchEvents(ctx, search)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func parseSearchDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func parseSearchPrice(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, errors.New("invalid price")
+	}
+	return &price, nil
 }
 
 func (ec *EventController) UpdateEvent(c *gin.Context) {
@@ -97,9 +200,9 @@ func (ec *EventController) UpdateEvent(c *gin.Context) {
 		return
 	}
 
-	updatedEvent, err := ec.service.UpdateEvent(ctx, id, updates)
+	updatedEvent, err := ec.service.UpdateEvent(ctx, id, updates, auth.OrganizerScope(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondManageError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -107,6 +210,7 @@ func (ec *EventController) UpdateEvent(c *gin.Context) {
 }
 
 func (ec *EventController) DeleteEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 
 	id := c.Param("id")
 	if id == "" {
@@ -114,15 +218,114 @@ func (ec *EventController) DeleteEvent(c *gin.Context) {
 		return
 	}
 
-	err := ec.service.DeleteEvent(id)
+	err := ec.service.DeleteEvent(ctx, id, auth.OrganizerScope(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondManageError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "event deleted successfully"})
 }
 
+func (ec *EventController) ChangeStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event id is missing"})
+		return
+	}
+
+	var body struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
+		return
+	}
+
+	event, err := ec.service.ChangeStatus(ctx, id, body.Status, auth.OrganizerScope(c))
+	if err != nil {
+		respondManageError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "event status updated successfully", "updatedEvent": event})
+}
+
+func (ec *EventController) CreateSeries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var series models.EventSeries
+	if err := c.ShouldBindJSON(&series); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	detail, err := ec.service.CreateSeries(ctx, &series, auth.OrganizerScope(c))
+	if err != nil {
+		respondManageError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "series created successfully", "data": detail})
+}
+
+func (ec *EventController) GetSeries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "series id is missing"})
+		return
+	}
+
+	detail, err := ec.service.GetSeries(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "series not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+func (ec *EventController) UpdateSeries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "series id is missing"})
+		return
+	}
+
+	var changes models.EventSeries
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := ec.service.UpdateSeries(ctx, id, &changes, auth.OrganizerScope(c))
+	if err != nil {
+		respondManageError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "series updated successfully", "data": report})
+}
+
+// respondManageError answers a failed change to an event or series, with
+// 403 when an organizer tried to manage someone else's and 409 when it
+// clashes with another event at the venue.
+func respondManageError(c *gin.Context, status int, err error) {
+	if errors.Is(err, service.ErrNotOwner) || errors.Is(err, service.ErrSeriesNotOwner) {
+		status = http.StatusForbidden
+	}
+	if errors.Is(err, service.ErrVenueConflict) {
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
 func (ec *EventController) GetCapacityUtilization(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -130,12 +333,25 @@ func (ec *EventController) GetCapacityUtilization(c *gin.Context) {
 	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
 
-	analytics, err := ec.service.GetCapacityUtilization(ctx, eventId, page, limit)
+	cursor, cursorMode := c.GetQuery("cursor")
+
+	analytics, info, err := ec.service.GetCapacityUtilization(ctx, eventId, auth.OrganizerScope(c), page, limit, cursor)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// page/limit callers still get the bare list; passing a cursor, even an
+	// empty one for the first page, switches to the paged form
+	if cursorMode {
+		c.JSON(http.StatusOK, gin.H{"results": analytics, "next_cursor": info.NextCursor, "has_more": info.HasMore})
+		return
+	}
+
 	c.JSON(http.StatusOK, analytics)
 
 }
@@ -146,7 +362,7 @@ func (ec *EventController) GetMostBookedEvents(c *gin.Context) {
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
 
 
-	analytics, err := ec.service.GetMostBookedEvents(ctx, limit)
+	analytics, err := ec.service.GetMostBookedEvents(ctx, auth.OrganizerScope(c), limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -162,7 +378,7 @@ func (ec *EventController) GetMostPopularEvents(c *gin.Context) {
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
 
 
-	analytics, err := ec.service.GetMostPopularEvents(ctx, limit)
+	analytics, err := ec.service.GetMostPopularEvents(ctx, auth.OrganizerScope(c), limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -170,4 +386,16 @@ func (ec *EventController) GetMostPopularEvents(c *gin.Context) {
 
 	c.JSON(http.StatusOK, analytics)
 
-}
\ No newline at end of file
+}
+
+func (ec *EventController) WarmCounters(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	report, err := ec.service.WarmCounters(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"events/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingController struct {
+	service service.PricingService
+}
+
+func NewPricingController(s service.PricingService) *PricingController {
+	return &PricingController{service: s}
+}
+
+func (pc *PricingController) ApplyPricing(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	changed, err := pc.service.ApplyPricing(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prices_changed": changed})
+}
+
+func (pc *PricingController) GetPriceHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event id is missing"})
+		return
+	}
+
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+
+	history, err := pc.service.GetPriceHistory(ctx, id, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event_id": id, "prices": history})
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"events/models"
+	"events/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromoController struct {
+	service service.PromoService
+}
+
+func NewPromoController(s service.PromoService) *PromoController {
+	return &PromoController{service: s}
+}
+
+func (pc *PromoController) CreatePromo(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var promo models.PromoCode
+	if err := c.ShouldBindJSON(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := pc.service.CreatePromo(ctx, &promo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "promo code created successfully", "data": created})
+}
+
+func (pc *PromoController) GetAllPromos(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	promos, err := pc.service.GetAllPromos(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promos": promos})
+}
+
+func (pc *PromoController) GetPromo(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	promo, err := pc.service.GetPromo(ctx, c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+func (pc *PromoController) UpdatePromo(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var promo models.PromoCode
+	if err := c.ShouldBindJSON(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	updated, err := pc.service.UpdatePromo(ctx, c.Param("code"), &promo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "promo code updated successfully", "data": updated})
+}
+
+func (pc *PromoController) DeletePromo(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := pc.service.DeletePromo(ctx, c.Param("code")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "promo code deleted successfully"})
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"events/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconcileController struct {
+	service service.ReconcileService
+}
+
+func NewReconcileController(s service.ReconcileService) *ReconcileController {
+	return &ReconcileController{service: s}
+}
+
+func (rc *ReconcileController) Reconcile(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	repair := c.DefaultQuery("repair", "false") == "true"
+
+	report, err := rc.service.Reconcile(ctx, repair)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (rc *ReconcileController) GetLastReport(c *gin.Context) {
+	report := rc.service.LastReport()
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no reconciliation has run yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
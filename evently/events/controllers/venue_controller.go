@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"events/models"
+	"events/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VenueController struct {
+	service service.VenueService
+}
+
+func NewVenueController(s service.VenueService) *VenueController {
+	return &VenueController{service: s}
+}
+
+func (vc *VenueController) CreateVenue(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var venue models.Venue
+	if err := c.ShouldBindJSON(&venue); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := vc.service.CreateVenue(ctx, &venue)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "venue created successfully", "data": created})
+}
+
+func (vc *VenueController) GetAllVenues(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	venues, err := vc.service.GetAllVenues(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"venues": venues})
+}
+
+func (vc *VenueController) GetVenue(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	venue, err := vc.service.GetVenue(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+func (vc *VenueController) UpdateVenue(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var venue models.Venue
+	if err := c.ShouldBindJSON(&venue); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	updated, err := vc.service.UpdateVenue(ctx, c.Param("id"), &venue)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "venue updated successfully", "data": updated})
+}
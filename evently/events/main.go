@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"events/auth"
@@ -15,6 +17,8 @@ import (
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -65,24 +69,84 @@ func main() {
 
 	)
 
-	eventService := service.NewEventService(repo, redisClient, redisSeats, redisPrice)
+	bookingsDB := connectBookingsDB()
+	bookingsRepo := repository.NewBookingsRepository(bookingsDB)
+
+	venueRepo := repository.NewVenueRepository(db)
+
+	eventService := service.NewEventService(repo, repository.NewSeriesRepository(db), venueRepo, bookingsRepo, redisClient, redisSeats, redisPrice)
 	eventController := controllers.NewEventController(eventService)
 
+	if _, err := eventService.WarmCounters(context.Background()); err != nil {
+		log.Println("Failed to warm up Redis counters:", err)
+	}
+
+	venueController := controllers.NewVenueController(service.NewVenueService(venueRepo, repo, redisClient))
+
+	promoService := service.NewPromoService(repository.NewPromoRepository(db), redisPrice)
+	promoController := controllers.NewPromoController(promoService)
+
+	if err := promoService.CachePromos(context.Background()); err != nil {
+		log.Println("Failed to cache promo codes:", err)
+	}
+
+	pricingService := service.NewPricingService(repo, repository.NewPriceHistoryRepository(db), redisSeats, redisPrice)
+	pricingController := controllers.NewPricingController(pricingService)
+
+	if minutes, _ := strconv.Atoi(os.Getenv("PRICING_INTERVAL_MINUTES")); minutes > 0 {
+		go service.StartPricingScheduler(context.Background(), pricingService, time.Duration(minutes)*time.Minute)
+	}
+
+	reconcileService := service.NewReconcileService(repo, bookingsRepo, redisClient, redisSeats)
+	reconcileController := controllers.NewReconcileController(reconcileService)
+
+	if minutes, _ := strconv.Atoi(os.Getenv("RECONCILE_INTERVAL_MINUTES")); minutes > 0 {
+		repair := os.Getenv("RECONCILE_AUTO_REPAIR") == "true"
+		go service.StartReconciler(context.Background(), reconcileService, time.Duration(minutes)*time.Minute, repair)
+	}
+
 	r := gin.Default()
 	api := r.Group("/api/v1")
 	{
 		api.GET("/events/all", eventController.GetAllEvents)
 		api.GET("/events/upcoming", eventController.GetAllUpcomingEvents)
+		api.GET("/events/search", eventController.SearchEvents)
+		api.GET("/events/series/:id", eventController.GetSeries)
+		api.GET("/events/venues", venueController.GetAllVenues)
+		api.GET("/events/venues/:id", venueController.GetVenue)
 		api.GET("/events/:id", eventController.GetEventByID)
+		api.GET("/events/:id/seats", eventController.GetSeatMap)
+
+		// organizers manage and see analytics for their own events only
+		manage := api.Group("/events")
+		manage.Use(auth.EventManagers())
+		{
+			manage.POST("/create", eventController.CreateEvent)
+			manage.PUT("/:id", eventController.UpdateEvent)
+			manage.DELETE("/:id", eventController.DeleteEvent)
+			manage.POST("/:id/status", eventController.ChangeStatus)
+			manage.POST("/series", eventController.CreateSeries)
+			manage.PUT("/series/:id", eventController.UpdateSeries)
+			manage.GET("/analytics/capacityUtil", eventController.GetCapacityUtilization)
+			manage.GET("/analytics/mostBooked", eventController.GetMostBookedEvents)
+			manage.GET("/analytics/mostPopular", eventController.GetMostPopularEvents)
+		}
 
 		admin := api.Group("/events")
 		admin.Use(auth.AdminOnly())
 		{
-			admin.POST("/create", eventController.CreateEvent)
-			admin.PUT("/:id", eventController.UpdateEvent)
-			admin.GET("/analytics/capacityUtil", eventController.GetCapacityUtilization)
-			admin.GET("/analytics/mostBooked", eventController.GetMostBookedEvents)
-			admin.GET("/analytics/mostPopular", eventController.GetMostPopularEvents)
+			admin.POST("/reconcile", reconcileController.Reconcile)
+			admin.GET("/reconcile/last", reconcileController.GetLastReport)
+			admin.POST("/warmup", eventController.WarmCounters)
+			admin.POST("/venues", venueController.CreateVenue)
+			admin.PUT("/venues/:id", venueController.UpdateVenue)
+			admin.POST("/promos", promoController.CreatePromo)
+			admin.GET("/promos", promoController.GetAllPromos)
+			admin.GET("/promos/:code", promoController.GetPromo)
+			admin.PUT("/promos/:code", promoController.UpdatePromo)
+			admin.DELETE("/promos/:code", promoController.DeletePromo)
+			admin.POST("/pricing/apply", pricingController.ApplyPricing)
+			admin.GET("/:id/prices", pricingController.GetPriceHistory)
 
 		}
 	}
@@ -95,6 +159,32 @@ if err := r.Run("0.0.0.0:" + port); err != nil {
 
 }
 
+func connectBookingsDB() *gorm.DB {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		mustGetEnv("POSTGRES_BOOKINGS_HOST"),
+		mustGetEnv("POSTGRES_BOOKINGS_USER"),
+		mustGetEnv("POSTGRES_BOOKINGS_PASSWORD"),
+		mustGetEnv("POSTGRES_BOOKINGS_DB"),
+		mustGetEnv("POSTGRES_BOOKINGS_PORT"),
+	)
+
+	var db *gorm.DB
+	var err error
+	for i := 0; i < 10; i++ {
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		if err == nil {
+			break
+		}
+		log.Println("Waiting for Bookings Postgres to be ready...")
+		time.Sleep(3 * time.Second)
+	}
+	if err != nil {
+		log.Fatal("Failed to connect to bookings database:", err)
+	}
+
+	return db
+}
+
 func newRedisClient(host, port, pass string) *redis.Client {
 	addr := host + ":" + port
 	rdb := redis.NewClient(&redis.Options{
@@ -11,41 +11,359 @@ type Event struct {
 	Title          string                 `bson:"title" json:"title"`
 	Description    string                 `bson:"description,omitempty" json:"description"`
 	Venue          string                 `bson:"venue" json:"venue"`
+	VenueID        string                 `bson:"venue_id,omitempty" json:"venue_id,omitempty"`
+	Category       string                 `bson:"category,omitempty" json:"category,omitempty"`
+	Tags           []string               `bson:"tags,omitempty" json:"tags,omitempty"`
+	OrganizerID    string                 `bson:"organizer_id,omitempty" json:"organizer_id,omitempty"`
+	SeriesID       string                 `bson:"series_id,omitempty" json:"series_id,omitempty"`
+	SeriesOverride bool                   `bson:"series_overridden,omitempty" json:"series_overridden,omitempty"`
 	Date           time.Time              `bson:"date" json:"date"`
+	Duration       int64                  `bson:"duration_minutes,omitempty" json:"duration_minutes,omitempty"`
 	Price          float64                `bson:"price" json:"price"`
 	AvailableSeats int64                  `bson:"available_seats" json:"available_seats"`
 	TotalSeats     int64                  `bson:"total_seats" json:"total_seats"`
+	Status         string                 `bson:"status" json:"status"`
+	SalesStart     *time.Time             `bson:"sales_start,omitempty" json:"sales_start,omitempty"`
+	SalesEnd       *time.Time             `bson:"sales_end,omitempty" json:"sales_end,omitempty"`
+	SeatMap        *SeatMap               `bson:"seat_map,omitempty" json:"seat_map,omitempty"`
+	Tiers          []TicketTier           `bson:"tiers,omitempty" json:"tiers,omitempty"`
+	RefundPolicy   *RefundPolicy          `bson:"refund_policy,omitempty" json:"refund_policy,omitempty"`
+	PricingRules   *PricingRules          `bson:"pricing_rules,omitempty" json:"pricing_rules,omitempty"`
 	Metadata       map[string]interface{} `bson:",inline"`
 	CreatedAt      time.Time              `bson:"created_at" json:"created_at"`
 	UpdatedAt      time.Time              `bson:"updated_at" json:"updated_at"`
 }
 
+// TicketTier is a class of ticket (VIP, early-bird, ...) with its own price
+// and seat pool. An event's tiers split its total_seats between them.
+type TicketTier struct {
+	Name           string  `bson:"name" json:"name"`
+	Price          float64 `bson:"price" json:"price"`
+	TotalSeats     int64   `bson:"total_seats" json:"total_seats"`
+	AvailableSeats int64   `bson:"available_seats" json:"available_seats"`
+}
+
+type SeatMap struct {
+	Sections []SeatSection `bson:"sections" json:"sections"`
+}
+
+type SeatSection struct {
+	Name string    `bson:"name" json:"name"`
+	Rows []SeatRow `bson:"rows" json:"rows"`
+}
+
+type SeatRow struct {
+	Name  string   `bson:"name" json:"name"`
+	Seats []string `bson:"seats" json:"seats"`
+}
+
+type SeatStatus struct {
+	SeatID    string `json:"seat_id"`
+	Section   string `json:"section"`
+	Row       string `json:"row"`
+	Number    string `json:"number"`
+	Available bool   `json:"available"`
+}
+
+// Venue is a room events are held in. No event there may sell more seats
+// than its Capacity, and Layout, when set, is the seat map events at the
+// venue get unless they bring seats of their own. Timezone is an IANA name.
+type Venue struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	Name      string             `bson:"name" json:"name"`
+	Address   string             `bson:"address" json:"address"`
+	Capacity  int64              `bson:"capacity" json:"capacity"`
+	Timezone  string             `bson:"timezone" json:"timezone"`
+	Layout    *SeatMap           `bson:"layout,omitempty" json:"layout,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// RefundPolicy decides how much of a booking is paid back on cancellation.
+// The rule with the largest DaysBefore that is still at or below the days
+// left until the event applies, e.g. {7, 100} and {0, 50} means a full
+// refund up to a week before and half after that.
+type RefundPolicy struct {
+	Rules []RefundRule `bson:"rules" json:"rules"`
+}
+
+type RefundRule struct {
+	DaysBefore int64   `bson:"days_before" json:"days_before"`
+	Percent    float64 `bson:"percent" json:"percent"`
+}
+
+// PricingRules scale an event's base price as it fills up and as the date
+// gets closer. The occupancy band with the highest MinOccupancy reached and
+// the time band with the smallest WithinDays still covering the days left
+// apply, and their multipliers are combined, e.g. {80, 1.5} and {3, 1.2}
+// charge 1.8x the base price for an event over 80% full within 3 days.
+type PricingRules struct {
+	Occupancy []OccupancyBand `bson:"occupancy,omitempty" json:"occupancy,omitempty"`
+	Time      []TimeBand      `bson:"time,omitempty" json:"time,omitempty"`
+}
+
+type OccupancyBand struct {
+	MinOccupancy float64 `bson:"min_occupancy" json:"min_occupancy"`
+	Multiplier   float64 `bson:"multiplier" json:"multiplier"`
+}
+
+type TimeBand struct {
+	WithinDays int64   `bson:"within_days" json:"within_days"`
+	Multiplier float64 `bson:"multiplier" json:"multiplier"`
+}
+
+// EventSeries is a recurring event: a template every occurrence is made
+// from and the rule saying when the occurrences are. Each occurrence is an
+// ordinary Event with its own seats and price keys and the series' id.
+type EventSeries struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	Template    SeriesTemplate     `bson:"template" json:"template"`
+	Recurrence  RecurrenceRule     `bson:"recurrence" json:"recurrence"`
+	OrganizerID string             `bson:"organizer_id,omitempty" json:"organizer_id,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// SeriesTemplate holds the fields every occurrence of a series starts with.
+// Status is the status new occurrences are created in.
+type SeriesTemplate struct {
+	Title        string        `bson:"title" json:"title"`
+	Description  string        `bson:"description,omitempty" json:"description,omitempty"`
+	Venue        string        `bson:"venue" json:"venue"`
+	VenueID      string        `bson:"venue_id" json:"venue_id"`
+	Duration     int64         `bson:"duration_minutes,omitempty" json:"duration_minutes,omitempty"`
+	Category     string        `bson:"category,omitempty" json:"category,omitempty"`
+	Tags         []string      `bson:"tags,omitempty" json:"tags,omitempty"`
+	Price        float64       `bson:"price" json:"price"`
+	TotalSeats   int64         `bson:"total_seats" json:"total_seats"`
+	Status       string        `bson:"status,omitempty" json:"status,omitempty"`
+	SeatMap      *SeatMap      `bson:"seat_map,omitempty" json:"seat_map,omitempty"`
+	Tiers        []TicketTier  `bson:"tiers,omitempty" json:"tiers,omitempty"`
+	RefundPolicy *RefundPolicy `bson:"refund_policy,omitempty" json:"refund_policy,omitempty"`
+	PricingRules *PricingRules `bson:"pricing_rules,omitempty" json:"pricing_rules,omitempty"`
+}
+
+// RecurrenceRule is a cut-down RRULE. Occurrences start at Start and repeat
+// every Interval days, weeks or months; weekly rules may list the weekdays
+// (MO..SU) they fall on. The series ends after Count occurrences or at
+// Until, whichever comes first, and Exceptions are dates (YYYY-MM-DD) that
+// are skipped.
+type RecurrenceRule struct {
+	Frequency  string     `bson:"frequency" json:"frequency"`
+	Interval   int        `bson:"interval,omitempty" json:"interval,omitempty"`
+	ByDay      []string   `bson:"by_day,omitempty" json:"by_day,omitempty"`
+	Start      time.Time  `bson:"start" json:"start"`
+	Until      *time.Time `bson:"until,omitempty" json:"until,omitempty"`
+	Count      int        `bson:"count,omitempty" json:"count,omitempty"`
+	Exceptions []string   `bson:"exceptions,omitempty" json:"exceptions,omitempty"`
+}
+
+// SeriesDetail is a series with its occurrences, oldest first.
+type SeriesDetail struct {
+	Series      EventSeries `json:"series"`
+	Occurrences []Event     `json:"occurrences"`
+}
+
+// SeriesUpdateReport says what an edit to a series did to its future
+// occurrences. Kept are occurrences the new schedule no longer has but that
+// were already published, so they stay until cancelled by hand.
+type SeriesUpdateReport struct {
+	Series  EventSeries `json:"series"`
+	Updated int         `json:"updated"`
+	Skipped int         `json:"skipped_overridden"`
+	Created int         `json:"created"`
+	Removed int         `json:"removed"`
+	Kept    []string    `json:"kept,omitempty"`
+}
+
+// PriceChange is one adjustment the pricing scheduler made to the price:
+// key of an event or one of its tiers.
+type PriceChange struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	EventID    string             `bson:"event_id" json:"event_id"`
+	Tier       string             `bson:"tier,omitempty" json:"tier,omitempty"`
+	OldPrice   float64            `bson:"old_price" json:"old_price"`
+	Price      float64            `bson:"price" json:"price"`
+	Multiplier float64            `bson:"multiplier" json:"multiplier"`
+	Occupancy  float64            `bson:"occupancy" json:"occupancy"`
+	DaysLeft   int64              `bson:"days_left" json:"days_left"`
+	ChangedAt  time.Time          `bson:"changed_at" json:"changed_at"`
+}
+
 type UpcomingEvent struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
 	Title          string             `bson:"title" json:"title"`
 	Venue          string             `bson:"venue" json:"venue"`
+	Category       string             `bson:"category,omitempty" json:"category,omitempty"`
+	Tags           []string           `bson:"tags,omitempty" json:"tags,omitempty"`
 	Date           time.Time          `bson:"date" json:"date"`
 	AvailableSeats int64              `bson:"available_seats" json:"available_seats"`
 	TotalSeats     int64              `bson:"total_seats" json:"total_seats"`
+	Status         string             `bson:"status" json:"status"`
+}
+
+// UpcomingFilter narrows the upcoming listing to a category and/or a tag.
+type UpcomingFilter struct {
+	Category string
+	Tag      string
+}
+
+// Cursor is where a keyset-paginated listing carries on from: the sort key
+// of the last item returned, a time or a number, and that item's id to
+// break ties.
+type Cursor struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v,omitempty"`
+	ID    string    `json:"id"`
+}
+
+// PageInfo tells a client whether a listing has more items and the cursor
+// to pass to get them.
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// EventSearch holds the filters of an event search. Nil bounds are left
+// open; From defaults to now so only upcoming events are found.
+type EventSearch struct {
+	Query     string
+	Venue     string
+	From      *time.Time
+	To        *time.Time
+	MinPrice  *float64
+	MaxPrice  *float64
+	Available bool
+	Sort      string
+	Page      int64
+	Limit     int64
+}
+
+// EventSearchHit is one event found by a search. Score is the text match
+// relevance and is only set when the search has a query.
+type EventSearchHit struct {
+	ID             primitive.ObjectID `bson:"_id" json:"_id"`
+	Title          string             `bson:"title" json:"title"`
+	Description    string             `bson:"description,omitempty" json:"description,omitempty"`
+	Venue          string             `bson:"venue" json:"venue"`
+	Date           time.Time          `bson:"date" json:"date"`
+	Price          float64            `bson:"price" json:"price"`
+	AvailableSeats int64              `bson:"available_seats" json:"available_seats"`
+	TotalSeats     int64              `bson:"total_seats" json:"total_seats"`
+	Status         string             `bson:"status" json:"status"`
+	Score          float64            `bson:"score,omitempty" json:"score,omitempty"`
+}
+
+// FacetCount is how many events of a search share a venue or month.
+type FacetCount struct {
+	Value string `bson:"_id" json:"value"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+type EventSearchFacets struct {
+	Venues []FacetCount `json:"venues"`
+	Months []FacetCount `json:"months"`
+}
+
+type EventSearchResult struct {
+	Events []EventSearchHit  `json:"events"`
+	Total  int64             `json:"total"`
+	Page   int64             `json:"page"`
+	Limit  int64             `json:"limit"`
+	Facets EventSearchFacets `json:"facets"`
 }
 
 type MostBookedEvent struct {
-    EventID     string `bson:"event_id" json:"event_id"`
-    Name        string `bson:"title" json:"title"`
-    BookedSeats int64  `bson:"booked_seats" json:"booked_seats"`
+    EventID     string         `bson:"event_id" json:"event_id"`
+    Name        string         `bson:"title" json:"title"`
+    BookedSeats int64          `bson:"booked_seats" json:"booked_seats"`
+    Tiers       []TierBookings `bson:"tiers" json:"tiers,omitempty"`
 }
 
 type MostPopularEvent struct {
-    EventID       string  `bson:"event_id" json:"event_id"`
-    Name          string  `bson:"title" json:"title"`
-    BookedSeats   int64   `bson:"booked_seats" json:"booked_seats"`
-    TotalSeats    int64   `bson:"total_seats" json:"total_seats"`
-    OccupancyRate float64 `bson:"occupancy_rate" json:"occupancy_rate"`
+    EventID       string         `bson:"event_id" json:"event_id"`
+    Name          string         `bson:"title" json:"title"`
+    BookedSeats   int64          `bson:"booked_seats" json:"booked_seats"`
+    TotalSeats    int64          `bson:"total_seats" json:"total_seats"`
+    OccupancyRate float64        `bson:"occupancy_rate" json:"occupancy_rate"`
+    Tiers         []TierBookings `bson:"tiers" json:"tiers,omitempty"`
 }
 
 type CapacityUtilization struct {
-    EventID         string  `bson:"event_id" json:"event_id"`
-    Title           string  `bson:"title" json:"title"`
-    CapacityUtilization float64 `bson:"capacity_utilisation" json:"capacity_utilisation"`
+    EventID             string         `bson:"event_id" json:"event_id"`
+    Title               string         `bson:"title" json:"title"`
+    CapacityUtilization float64        `bson:"capacity_utilisation" json:"capacity_utilisation"`
+    Tiers               []TierBookings `bson:"tiers" json:"tiers,omitempty"`
+}
+
+// TierBookings is the per-tier breakdown attached to the analytics results.
+type TierBookings struct {
+	Name        string `bson:"name" json:"name"`
+	BookedSeats int64  `bson:"booked_seats" json:"booked_seats"`
+	TotalSeats  int64  `bson:"total_seats" json:"total_seats"`
 }
 
+type EventSeats struct {
+	ID             primitive.ObjectID `bson:"_id" json:"_id"`
+	Title          string             `bson:"title" json:"title"`
+	AvailableSeats int64              `bson:"available_seats" json:"available_seats"`
+	TotalSeats     int64              `bson:"total_seats" json:"total_seats"`
+}
+
+// BookedSeats is what Postgres says an event has sold. Booked seats are
+// final (confirmed or held); pending seats are taken in Redis while the
+// booking waits for payment but not yet in Mongo.
+type BookedSeats struct {
+	EventID string `json:"event_id"`
+	Booked  int64  `json:"booked"`
+	Pending int64  `json:"pending"`
+}
+
+type SeatDrift struct {
+	EventID       string `json:"event_id"`
+	Title         string `json:"title"`
+	TotalSeats    int64  `json:"total_seats"`
+	BookedSeats   int64  `json:"booked_seats"`
+	PendingSeats  int64  `json:"pending_seats"`
+	ExpectedMongo int64  `json:"expected_mongo"`
+	MongoSeats    int64  `json:"mongo_seats"`
+	ExpectedRedis int64  `json:"expected_redis"`
+	RedisSeats    *int64 `json:"redis_seats"`
+	RedisRepaired bool   `json:"redis_repaired"`
+	MongoRepaired bool   `json:"mongo_repaired"`
+}
+
+type ReconcileReport struct {
+	StartedAt     time.Time   `json:"started_at"`
+	FinishedAt    time.Time   `json:"finished_at"`
+	Repair        bool        `json:"repair"`
+	EventsChecked int         `json:"events_checked"`
+	Drifted       []SeatDrift `json:"drifted"`
+}
+
+type WarmupReport struct {
+	EventsChecked        int `json:"events_checked"`
+	SeatsRestored        int `json:"seats_restored"`
+	PricesRestored       int `json:"prices_restored"`
+	PoliciesRestored     int `json:"refund_policies_restored"`
+	SalesWindowsRestored int `json:"sales_windows_restored"`
+}
+
+// PromoCode discounts a booking, either by a percentage of its price or by
+// a fixed amount. Zero MaxUses or PerUserLimit means no cap and an empty
+// EventIDs means the code works for every event.
+type PromoCode struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	Code         string             `bson:"code" json:"code"`
+	Type         string             `bson:"type" json:"type"`
+	Value        float64            `bson:"value" json:"value"`
+	MaxUses      int64              `bson:"max_uses" json:"max_uses"`
+	PerUserLimit int64              `bson:"per_user_limit" json:"per_user_limit"`
+	ValidFrom    time.Time          `bson:"valid_from" json:"valid_from"`
+	ValidUntil   time.Time          `bson:"valid_until" json:"valid_until"`
+	EventIDs     []string           `bson:"event_ids,omitempty" json:"event_ids,omitempty"`
+	Active       bool               `bson:"active" json:"active"`
+	Uses         int64              `bson:"-" json:"uses"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
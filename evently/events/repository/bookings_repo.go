@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"events/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookingsRepository reads the bookings Postgres database, which is the
+// source of truth for how many seats each event has sold.
+type BookingsRepository interface {
+	GetBookedSeats(ctx context.Context) (map[string]models.BookedSeats, error)
+	GetTakenSeatIDs(ctx context.Context, eventID string) ([]string, error)
+	HasBookings(ctx context.Context, eventID string) (bool, error)
+	QueueEventCancellation(ctx context.Context, topic, eventID string) error
+}
+
+// outboxMessage is a row of the bookings database's outbox, which the
+// bookings consumer's relay publishes to Kafka.
+type outboxMessage struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	Topic         string    `gorm:"type:varchar(255);not null"`
+	Key           string    `gorm:"type:varchar(255);not null"`
+	Payload       string    `gorm:"type:text;not null"`
+	NextAttemptAt time.Time `gorm:"index"`
+}
+
+func (outboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+type bookingsRepo struct {
+	db *gorm.DB
+}
+
+func NewBookingsRepository(db *gorm.DB) BookingsRepository {
+	return &bookingsRepo{db: db}
+}
+
+func (r *bookingsRepo) GetBookedSeats(ctx context.Context) (map[string]models.BookedSeats, error) {
+	var rows []models.BookedSeats
+
+	err := r.db.WithContext(ctx).
+		Table("bookings").
+		Select("event_id, " +
+			"COALESCE(SUM(CASE WHEN status IN ('confirmed', 'held') THEN seats ELSE 0 END), 0) AS booked, " +
+			"COALESCE(SUM(CASE WHEN status = 'payment_pending' THEN seats ELSE 0 END), 0) AS pending").
+		Group("event_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.BookedSeats, len(rows))
+	for _, row := range rows {
+		result[row.EventID] = row
+	}
+
+	return result, nil
+}
+
+// GetTakenSeatIDs lists the seats of a seat-mapped event that confirmed,
+// held and payment_pending bookings occupy.
+func (r *bookingsRepo) GetTakenSeatIDs(ctx context.Context, eventID string) ([]string, error) {
+	var rows []string
+
+	err := r.db.WithContext(ctx).
+		Table("bookings").
+		Where("event_id = ? AND status IN ? AND seat_ids <> ''", eventID, []string{"confirmed", "held", "payment_pending"}).
+		Pluck("seat_ids", &rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, row := range rows {
+		ids = append(ids, strings.Split(row, ",")...)
+	}
+
+	return ids, nil
+}
+
+// HasBookings tells whether anyone has booked the event, including bookings
+// since cancelled. Requests that failed or whose payment was declined never
+// held seats and do not count.
+func (r *bookingsRepo) HasBookings(ctx context.Context, eventID string) (bool, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Table("bookings").
+		Where("event_id = ? AND status NOT IN ?", eventID, []string{"failed", "payment_failed"}).
+		Limit(1).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// QueueEventCancellation asks the cancel consumer to cancel and refund
+// every booking of a cancelled event.
+func (r *bookingsRepo) QueueEventCancellation(ctx context.Context, topic, eventID string) error {
+	payload, err := json.Marshal(map[string]string{"event_id": eventID})
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Create(&outboxMessage{
+		Topic:         topic,
+		Key:           "eventCancelled:" + eventID,
+		Payload:       string(payload),
+		NextAttemptAt: time.Now(),
+	}).Error
+}
@@ -15,14 +15,23 @@ import (
 type EventRepository interface {
 	Create(event *models.Event) (*models.Event, error)
 	FindByID(id string) (*models.Event, error)
-	FindAll(page int64, limit int64) ([]models.Event, error)
-	FindAllUpcomingEvents(page, limit int64) ([]models.UpcomingEvent, error)
+	FindAll(page int64, limit int64, after *models.Cursor) ([]models.Event, error)
+	FindAllUpcomingEvents(page, limit int64, after *models.Cursor, filter models.UpcomingFilter) ([]models.UpcomingEvent, error)
 	FindAvailableSeatsForIds(ids []string) (map[string]int64, error)
+	FindSeatCounts(ctx context.Context) ([]models.EventSeats, error)
+	FindUpcomingCounters(ctx context.Context) ([]models.Event, error)
+	FindUpcomingPriced(ctx context.Context) ([]models.Event, error)
+	FindBySeries(ctx context.Context, seriesID string) ([]models.Event, error)
+	FindVenueConflicts(ctx context.Context, venueID string, start, end time.Time, excludeID, excludeSeries string) ([]models.Event, error)
+	FindUpcomingAtVenue(ctx context.Context, venueID string) ([]models.Event, error)
+	RenameVenue(ctx context.Context, venueID, name string) error
 	UpdateFields(id string, updates map[string]interface{}) error
-	GetCapacityUtilization(ctx context.Context, eventID string, page, limit int64) ([]models.CapacityUtilization, error)
-	GetMostBookedEvents(ctx context.Context, limit int64) ([]models.MostBookedEvent, error)
-	GetMostPopularEvents(ctx context.Context, limit int64) ([]models.MostPopularEvent, error)
+	UpdateStatus(ctx context.Context, id, from, to string) error
+	GetCapacityUtilization(ctx context.Context, eventID, organizerID string, page, limit int64, after *models.Cursor) ([]models.CapacityUtilization, error)
+	GetMostBookedEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostBookedEvent, error)
+	GetMostPopularEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostPopularEvent, error)
 	Delete(id string) error
+	Search(ctx context.Context, search models.EventSearch) (*models.EventSearchResult, error)
 }
 
 type eventRepo struct {
@@ -31,8 +40,11 @@ type eventRepo struct {
 }
 
 func NewEventRepository(db *mongo.Database) EventRepository {
+	collection := db.Collection("events")
+	ensureIndexes(collection)
+
 	return &eventRepo{
-		collection: db.Collection("events"),
+		collection: collection,
 		ctx:        context.Background(),
 	}
 }
@@ -103,15 +115,221 @@ func (r *eventRepo) FindAvailableSeatsForIds(ids []string) (map[string]int64, er
 	return result, nil
 }
 
-func (r *eventRepo) FindAll(page int64, limit int64) ([]models.Event, error) {
-	skip := (page - 1) * limit
+func (r *eventRepo) FindSeatCounts(ctx context.Context) ([]models.EventSeats, error) {
+	projection := options.Find().SetProjection(bson.M{
+		"title":           1,
+		"available_seats": 1,
+		"total_seats":     1,
+	})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, projection)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.EventSeats
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// FindUpcomingCounters loads just the fields the booking path reads from
+// Redis for every event that has not happened yet.
+func (r *eventRepo) FindUpcomingCounters(ctx context.Context) ([]models.Event, error) {
+	projection := options.Find().SetProjection(bson.M{
+		"date":            1,
+		"price":           1,
+		"available_seats": 1,
+		"refund_policy":   1,
+		"tiers":           1,
+		"status":          1,
+		"sales_start":     1,
+		"sales_end":       1,
+		"seat_map":        1,
+	})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"date": bson.M{"$gt": time.Now()}}, projection)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// FindBySeries loads every occurrence of a series, oldest first.
+func (r *eventRepo) FindBySeries(ctx context.Context, seriesID string) ([]models.Event, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"series_id": seriesID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// FindVenueConflicts loads the events still on at a venue that overlap the
+// time from start to end, leaving out the event excludeID and the
+// occurrences of excludeSeries. Events at a venue always store their
+// duration, so each one ends duration_minutes after its date.
+func (r *eventRepo) FindVenueConflicts(ctx context.Context, venueID string, start, end time.Time, excludeID, excludeSeries string) ([]models.Event, error) {
+	eventEnd := bson.M{"$add": bson.A{"$date", bson.M{"$multiply": bson.A{"$duration_minutes", 60 * 1000}}}}
+
+	filter := bson.M{
+		"venue_id": venueID,
+		"status":   bson.M{"$ne": "cancelled"},
+		"date":     bson.M{"$lt": end},
+		"$expr":    bson.M{"$gt": bson.A{eventEnd, start}},
+	}
+
+	if excludeID != "" {
+		eventId, err := primitive.ObjectIDFromHex(excludeID)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$ne": eventId}
+	}
+
+	if excludeSeries != "" {
+		filter["series_id"] = bson.M{"$ne": excludeSeries}
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "date", Value: 1}}).
+		SetLimit(5).
+		SetProjection(bson.M{"title": 1, "date": 1, "duration_minutes": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// FindUpcomingAtVenue loads the events at a venue that have not happened
+// yet and are not cancelled.
+func (r *eventRepo) FindUpcomingAtVenue(ctx context.Context, venueID string) ([]models.Event, error) {
+	filter := bson.M{
+		"venue_id": venueID,
+		"date":     bson.M{"$gt": time.Now()},
+		"status":   bson.M{"$ne": "cancelled"},
+	}
+
+	projection := options.Find().SetProjection(bson.M{
+		"title":       1,
+		"date":        1,
+		"total_seats": 1,
+	})
+
+	cursor, err := r.collection.Find(ctx, filter, projection)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// RenameVenue copies a venue's new name onto every event held there.
+func (r *eventRepo) RenameVenue(ctx context.Context, venueID, name string) error {
+	update := bson.M{"$set": bson.M{"venue": name, "updated_at": time.Now()}}
+
+	_, err := r.collection.UpdateMany(ctx, bson.M{"venue_id": venueID}, update)
+	return err
+}
+
+// FindUpcomingPriced loads the upcoming events that have pricing rules.
+func (r *eventRepo) FindUpcomingPriced(ctx context.Context) ([]models.Event, error) {
+	projection := options.Find().SetProjection(bson.M{
+		"date":            1,
+		"price":           1,
+		"available_seats": 1,
+		"total_seats":     1,
+		"tiers":           1,
+		"pricing_rules":   1,
+	})
+
+	filter := bson.M{
+		"date":          bson.M{"$gt": time.Now()},
+		"pricing_rules": bson.M{"$exists": true},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, projection)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// pageFetch is how many items to load for a page of limit: one more, so
+// the caller can tell whether a next page exists.
+func pageFetch(limit int64) int64 {
+	if limit <= 0 {
+		return limit
+	}
+	return limit + 1
+}
 
+// keysetFilter matches the items after the cursor in a listing sorted on
+// field and then _id, both in the direction op ($gt or $lt) goes.
+func keysetFilter(field string, value interface{}, id string, op string) bson.M {
+	oid, _ := primitive.ObjectIDFromHex(id)
+
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: oid}},
+	}}
+}
+
+// FindAll lists events newest first, one past limit. With after set it
+// carries on from that cursor instead of skipping pages.
+func (r *eventRepo) FindAll(page int64, limit int64, after *models.Cursor) ([]models.Event, error) {
 	findOptions := options.Find()
-	findOptions.SetLimit(limit)
-	findOptions.SetSkip(skip)
-	findOptions.SetSort(bson.D{{"created_at", -1}})
+	findOptions.SetLimit(pageFetch(limit))
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	filter := bson.M{}
+	if after != nil {
+		filter = keysetFilter("created_at", after.Time, after.ID, "$lt")
+	} else {
+		findOptions.SetSkip((page - 1) * limit)
+	}
 
-	cursor, err := r.collection.Find(r.ctx, bson.M{}, findOptions)
+	cursor, err := r.collection.Find(r.ctx, filter, findOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -133,14 +351,15 @@ func (r *eventRepo) FindAll(page int64, limit int64) ([]models.Event, error) {
 	return events, nil
 }
 
-func (r *eventRepo) FindAllUpcomingEvents(page, limit int64) ([]models.UpcomingEvent, error) {
-	skip := (page - 1) * limit
+func (r *eventRepo) FindAllUpcomingEvents(page, limit int64, after *models.Cursor, upcoming models.UpcomingFilter) ([]models.UpcomingEvent, error) {
 	now := time.Now()
 
 	findOptions := options.Find()
-	findOptions.SetLimit(limit)
-	findOptions.SetSkip(skip)
-	findOptions.SetSort(bson.D{{Key: "date", Value: 1}})
+	findOptions.SetLimit(pageFetch(limit))
+	findOptions.SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	if after == nil {
+		findOptions.SetSkip((page - 1) * limit)
+	}
 
 	findOptions.SetProjection(bson.M{
 		"title":           1,
@@ -148,9 +367,27 @@ func (r *eventRepo) FindAllUpcomingEvents(page, limit int64) ([]models.UpcomingE
 		"date":            1,
 		"available_seats": 1,
 		"total_seats":     1,
+		"status":          1,
+		"category":        1,
+		"tags":            1,
 	})
 
-	cursor, err := r.collection.Find(r.ctx, bson.M{"date": bson.M{"$gt": now}}, findOptions)
+	// drafts are not public yet and cancelled events will not happen
+	filter := bson.M{
+		"date":   bson.M{"$gt": now},
+		"status": bson.M{"$nin": bson.A{"draft", "cancelled"}},
+	}
+	if upcoming.Category != "" {
+		filter["category"] = upcoming.Category
+	}
+	if upcoming.Tag != "" {
+		filter["tags"] = upcoming.Tag
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, keysetFilter("date", after.Time, after.ID, "$gt")}}
+	}
+
+	cursor, err := r.collection.Find(r.ctx, filter, findOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +433,34 @@ func (r *eventRepo) UpdateFields(id string, updates map[string]interface{}) erro
 	return nil
 }
 
+// UpdateStatus moves the event from one lifecycle status to another, and
+// fails if someone else changed its status first. Events stored before they
+// had a status match an empty from.
+func (r *eventRepo) UpdateStatus(ctx context.Context, id, from, to string) error {
+	eventId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": eventId, "status": from}
+	if from == "" {
+		filter["status"] = bson.M{"$in": bson.A{"", nil}}
+	}
+
+	update := bson.M{"$set": bson.M{"status": to, "updated_at": time.Now()}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.New("event not found or its status changed meanwhile")
+	}
+
+	return nil
+}
+
 func (r *eventRepo) Delete(id string) error {
 	eventId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -214,7 +479,33 @@ func (r *eventRepo) Delete(id string) error {
 	return nil
 }
 
-func (r *eventRepo) GetMostBookedEvents(ctx context.Context, limit int64) ([]models.MostBookedEvent, error) {
+// tierBookingsProjection turns an event's tiers into the per-tier booked
+// seats reported next to the event-wide analytics.
+var tierBookingsProjection = bson.D{{
+	Key: "$map",
+	Value: bson.D{
+		{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$tiers", bson.A{}}}}},
+		{Key: "as", Value: "tier"},
+		{Key: "in", Value: bson.D{
+			{Key: "name", Value: "$$tier.name"},
+			{Key: "total_seats", Value: "$$tier.total_seats"},
+			{Key: "booked_seats", Value: bson.D{
+				{Key: "$subtract", Value: bson.A{"$$tier.total_seats", "$$tier.available_seats"}},
+			}},
+		}},
+	},
+}}
+
+// organizerStages limits an analytics pipeline to one organizer's events,
+// or to none when organizerID is empty.
+func organizerStages(organizerID string) mongo.Pipeline {
+	if organizerID == "" {
+		return mongo.Pipeline{}
+	}
+	return mongo.Pipeline{{{Key: "$match", Value: bson.D{{Key: "organizer_id", Value: organizerID}}}}}
+}
+
+func (r *eventRepo) GetMostBookedEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostBookedEvent, error) {
 
 	pipeline := mongo.Pipeline{
 		{{
@@ -225,6 +516,7 @@ func (r *eventRepo) GetMostBookedEvents(ctx context.Context, limit int64) ([]mod
 				{Key: "booked_seats", Value: bson.D{
 					{Key: "$subtract", Value: bson.A{"$total_seats", "$available_seats"}},
 				}},
+				{Key: "tiers", Value: tierBookingsProjection},
 			},
 		}},
 		{{
@@ -237,6 +529,8 @@ func (r *eventRepo) GetMostBookedEvents(ctx context.Context, limit int64) ([]mod
 		}},
 	}
 
+	pipeline = append(organizerStages(organizerID), pipeline...)
+
 	cur, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
@@ -251,7 +545,7 @@ func (r *eventRepo) GetMostBookedEvents(ctx context.Context, limit int64) ([]mod
 	return results, nil
 }
 
-func (r *eventRepo) GetMostPopularEvents(ctx context.Context, limit int64) ([]models.MostPopularEvent, error) {
+func (r *eventRepo) GetMostPopularEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostPopularEvent, error) {
 
 	pipeline := mongo.Pipeline{
 		{{
@@ -272,6 +566,7 @@ func (r *eventRepo) GetMostPopularEvents(ctx context.Context, limit int64) ([]mo
 						2,
 					}},
 				}},
+				{Key: "tiers", Value: tierBookingsProjection},
 			},
 		}},
 		{{
@@ -284,6 +579,8 @@ func (r *eventRepo) GetMostPopularEvents(ctx context.Context, limit int64) ([]mo
 		}},
 	}
 
+	pipeline = append(organizerStages(organizerID), pipeline...)
+
 	cur, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
@@ -298,14 +595,14 @@ func (r *eventRepo) GetMostPopularEvents(ctx context.Context, limit int64) ([]mo
 	return results, nil
 }
 
-func (r *eventRepo) GetCapacityUtilization(ctx context.Context, eventID string, page, limit int64) ([]models.CapacityUtilization, error) {
+func (r *eventRepo) GetCapacityUtilization(ctx context.Context, eventID, organizerID string, page, limit int64, after *models.Cursor) ([]models.CapacityUtilization, error) {
 
 	matchStage := bson.D{}
 	if eventID != "" {
 		matchStage = bson.D{{Key: "$match", Value: bson.D{{Key: "_id", Value: eventID}}}}
 	}
 
-	pipeline := mongo.Pipeline{}
+	pipeline := organizerStages(organizerID)
 
 	if len(matchStage) > 0 {
 		pipeline = append(pipeline, matchStage)
@@ -329,19 +626,28 @@ func (r *eventRepo) GetCapacityUtilization(ctx context.Context, eventID string,
 					2,
 				}},
 			}},
+			{Key: "tiers", Value: tierBookingsProjection},
 		},
 	}}
 
 	pipeline = append(pipeline, projectStage)
-	skip := (page - 1) * limit
 
-	pipeline = append(pipeline,
-		bson.D{{Key: "$sort", Value: bson.D{{Key: "capacity_utilisation", Value: -1}}}},
+	// capacity_utilisation only exists after the projection, so the cursor
+	// is applied there
+	if after != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: keysetFilter("capacity_utilisation", after.Value, after.ID, "$lt")}})
+	}
 
-		bson.D{{Key: "$skip", Value: skip}},
-		bson.D{{Key: "$limit", Value: limit}},
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "capacity_utilisation", Value: -1}, {Key: "_id", Value: -1}}}},
 	)
 
+	if after == nil {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: (page - 1) * limit}})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$limit", Value: pageFetch(limit)}})
+
 	cur, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"events/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// searchSorts maps the sort options of a search to their Mongo sort. A
+// search with a query is ranked by relevance unless it asks otherwise.
+var searchSorts = map[string]bson.D{
+	"date":      {{Key: "date", Value: 1}, {Key: "_id", Value: 1}},
+	"-date":     {{Key: "date", Value: -1}, {Key: "_id", Value: 1}},
+	"price":     {{Key: "price", Value: 1}, {Key: "_id", Value: 1}},
+	"-price":    {{Key: "price", Value: -1}, {Key: "_id", Value: 1}},
+	"seats":     {{Key: "available_seats", Value: -1}, {Key: "_id", Value: 1}},
+	"relevance": {{Key: "score", Value: -1}, {Key: "date", Value: 1}},
+}
+
+// ensureIndexes creates the text index free-text search runs on, with
+// title matches counting for more than description ones, and the indexes
+// the listing filters, organizer analytics, series and venue scheduling
+// use.
+func ensureIndexes(collection *mongo.Collection) {
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("event_text").
+				SetWeights(bson.D{{Key: "title", Value: 5}, {Key: "description", Value: 1}}),
+		},
+		{
+			Keys: bson.D{{Key: "venue", Value: 1}, {Key: "date", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "date", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "category", Value: 1}, {Key: "date", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}, {Key: "date", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "organizer_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "series_id", Value: 1}, {Key: "date", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "venue_id", Value: 1}, {Key: "date", Value: 1}},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to create event indexes: %v", err)
+	}
+}
+
+// searchFilter turns a search into a $match. Drafts and cancelled events
+// are never found.
+func searchFilter(search models.EventSearch) bson.M {
+	filter := bson.M{
+		"status": bson.M{"$nin": bson.A{"draft", "cancelled"}},
+	}
+
+	if search.Query != "" {
+		filter["$text"] = bson.M{"$search": search.Query}
+	}
+
+	if search.Venue != "" {
+		filter["venue"] = search.Venue
+	}
+
+	from := time.Now()
+	if search.From != nil {
+		from = *search.From
+	}
+	date := bson.M{"$gte": from}
+	if search.To != nil {
+		date["$lt"] = *search.To
+	}
+	filter["date"] = date
+
+	// an event is in the price range if its base price or any tier is
+	price := bson.M{}
+	if search.MinPrice != nil {
+		price["$gte"] = *search.MinPrice
+	}
+	if search.MaxPrice != nil {
+		price["$lte"] = *search.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["$or"] = bson.A{
+			bson.M{"price": price},
+			bson.M{"tiers": bson.M{"$elemMatch": bson.M{"price": price}}},
+		}
+	}
+
+	if search.Available {
+		filter["available_seats"] = bson.M{"$gt": 0}
+	}
+
+	return filter
+}
+
+// Search finds the events matching search and counts them by venue and by
+// month in the same aggregation.
+func (r *eventRepo) Search(ctx context.Context, search models.EventSearch) (*models.EventSearchResult, error) {
+	sort := searchSorts[search.Sort]
+	if search.Query == "" && search.Sort == "relevance" {
+		sort = searchSorts["date"]
+	}
+
+	projection := bson.D{
+		{Key: "title", Value: 1},
+		{Key: "description", Value: 1},
+		{Key: "venue", Value: 1},
+		{Key: "date", Value: 1},
+		{Key: "price", Value: 1},
+		{Key: "available_seats", Value: 1},
+		{Key: "total_seats", Value: 1},
+		{Key: "status", Value: 1},
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: searchFilter(search)}},
+	}
+
+	// the text score is kept as a field so the faceted sub-pipelines can
+	// sort on it
+	if search.Query != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}},
+		}}})
+		projection = append(projection, bson.E{Key: "score", Value: 1})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "events", Value: bson.A{
+				bson.D{{Key: "$sort", Value: sort}},
+				bson.D{{Key: "$skip", Value: (search.Page - 1) * search.Limit}},
+				bson.D{{Key: "$limit", Value: search.Limit}},
+				bson.D{{Key: "$project", Value: projection}},
+			}},
+			{Key: "total", Value: bson.A{
+				bson.D{{Key: "$count", Value: "count"}},
+			}},
+			{Key: "venues", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: "$venue"},
+					{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+				}}},
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+			}},
+			{Key: "months", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: bson.D{{Key: "$dateToString", Value: bson.D{
+						{Key: "format", Value: "%Y-%m"},
+						{Key: "date", Value: "$date"},
+					}}}},
+					{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+				}}},
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+			}},
+		}}},
+	)
+
+	cur, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var facets []struct {
+		Events []models.EventSearchHit `bson:"events"`
+		Total  []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Venues []models.FacetCount `bson:"venues"`
+		Months []models.FacetCount `bson:"months"`
+	}
+	if err := cur.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+
+	result := &models.EventSearchResult{
+		Events: []models.EventSearchHit{},
+		Page:   search.Page,
+		Limit:  search.Limit,
+		Facets: models.EventSearchFacets{Venues: []models.FacetCount{}, Months: []models.FacetCount{}},
+	}
+	if len(facets) == 0 {
+		return result, nil
+	}
+
+	if facets[0].Events != nil {
+		result.Events = facets[0].Events
+	}
+	if len(facets[0].Total) > 0 {
+		result.Total = facets[0].Total[0].Count
+	}
+	if facets[0].Venues != nil {
+		result.Facets.Venues = facets[0].Venues
+	}
+	if facets[0].Months != nil {
+		result.Facets.Months = facets[0].Months
+	}
+
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"events/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PriceHistoryRepository interface {
+	Record(ctx context.Context, change *models.PriceChange) error
+	FindByEvent(ctx context.Context, eventID string, page, limit int64) ([]models.PriceChange, error)
+}
+
+type priceHistoryRepo struct {
+	collection *mongo.Collection
+}
+
+func NewPriceHistoryRepository(db *mongo.Database) PriceHistoryRepository {
+	return &priceHistoryRepo{collection: db.Collection("price_history")}
+}
+
+func (r *priceHistoryRepo) Record(ctx context.Context, change *models.PriceChange) error {
+	change.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, change)
+	return err
+}
+
+func (r *priceHistoryRepo) FindByEvent(ctx context.Context, eventID string, page, limit int64) ([]models.PriceChange, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "changed_at", Value: -1}})
+	findOptions.SetSkip((page - 1) * limit)
+	findOptions.SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"event_id": eventID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []models.PriceChange
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PromoRepository interface {
+	Create(ctx context.Context, promo *models.PromoCode) (*models.PromoCode, error)
+	FindByCode(ctx context.Context, code string) (*models.PromoCode, error)
+	FindAll(ctx context.Context) ([]models.PromoCode, error)
+	Replace(ctx context.Context, code string, promo *models.PromoCode) error
+	Delete(ctx context.Context, code string) error
+}
+
+type promoRepo struct {
+	collection *mongo.Collection
+}
+
+func NewPromoRepository(db *mongo.Database) PromoRepository {
+	collection := db.Collection("promo_codes")
+
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Failed to create promo code index: %v", err)
+	}
+
+	return &promoRepo{collection: collection}
+}
+
+func (r *promoRepo) Create(ctx context.Context, promo *models.PromoCode) (*models.PromoCode, error) {
+	promo.ID = primitive.NewObjectID()
+	promo.CreatedAt = time.Now()
+	promo.UpdatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, promo); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("promo code already exists")
+		}
+		return nil, err
+	}
+
+	return promo, nil
+}
+
+func (r *promoRepo) FindByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+
+	err := r.collection.FindOne(ctx, bson.M{"code": code}).Decode(&promo)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("promo code not found")
+		}
+		return nil, err
+	}
+
+	return &promo, nil
+}
+
+func (r *promoRepo) FindAll(ctx context.Context) ([]models.PromoCode, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promos []models.PromoCode
+	if err := cursor.All(ctx, &promos); err != nil {
+		return nil, err
+	}
+
+	return promos, nil
+}
+
+func (r *promoRepo) Replace(ctx context.Context, code string, promo *models.PromoCode) error {
+	promo.UpdatedAt = time.Now()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"code": code}, bson.M{"$set": bson.M{
+		"type":           promo.Type,
+		"value":          promo.Value,
+		"max_uses":       promo.MaxUses,
+		"per_user_limit": promo.PerUserLimit,
+		"valid_from":     promo.ValidFrom,
+		"valid_until":    promo.ValidUntil,
+		"event_ids":      promo.EventIDs,
+		"active":         promo.Active,
+		"updated_at":     promo.UpdatedAt,
+	}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.New("promo code not found")
+	}
+
+	return nil
+}
+
+func (r *promoRepo) Delete(ctx context.Context, code string) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"code": code})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return errors.New("promo code not found")
+	}
+
+	return nil
+}
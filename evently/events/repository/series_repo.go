@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SeriesRepository interface {
+	Create(ctx context.Context, series *models.EventSeries) (*models.EventSeries, error)
+	FindByID(ctx context.Context, id string) (*models.EventSeries, error)
+	Replace(ctx context.Context, series *models.EventSeries) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type seriesRepo struct {
+	collection *mongo.Collection
+}
+
+func NewSeriesRepository(db *mongo.Database) SeriesRepository {
+	return &seriesRepo{collection: db.Collection("event_series")}
+}
+
+func (r *seriesRepo) Create(ctx context.Context, series *models.EventSeries) (*models.EventSeries, error) {
+	series.ID = primitive.NewObjectID()
+	series.CreatedAt = time.Now()
+	series.UpdatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, series); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (r *seriesRepo) FindByID(ctx context.Context, id string) (*models.EventSeries, error) {
+	seriesID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var series models.EventSeries
+	err = r.collection.FindOne(ctx, bson.M{"_id": seriesID}).Decode(&series)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("series not found")
+		}
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+func (r *seriesRepo) Replace(ctx context.Context, series *models.EventSeries) error {
+	series.UpdatedAt = time.Now()
+
+	res, err := r.collection.ReplaceOne(ctx, bson.M{"_id": series.ID}, series)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.New("series not found")
+	}
+
+	return nil
+}
+
+func (r *seriesRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
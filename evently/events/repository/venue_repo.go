@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type VenueRepository interface {
+	Create(ctx context.Context, venue *models.Venue) (*models.Venue, error)
+	FindByID(ctx context.Context, id string) (*models.Venue, error)
+	FindAll(ctx context.Context) ([]models.Venue, error)
+	Replace(ctx context.Context, venue *models.Venue) error
+}
+
+type venueRepo struct {
+	collection *mongo.Collection
+}
+
+func NewVenueRepository(db *mongo.Database) VenueRepository {
+	return &venueRepo{collection: db.Collection("venues")}
+}
+
+func (r *venueRepo) Create(ctx context.Context, venue *models.Venue) (*models.Venue, error) {
+	venue.ID = primitive.NewObjectID()
+	venue.CreatedAt = time.Now()
+	venue.UpdatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, venue); err != nil {
+		return nil, err
+	}
+
+	return venue, nil
+}
+
+func (r *venueRepo) FindByID(ctx context.Context, id string) (*models.Venue, error) {
+	venueID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("venue not found")
+	}
+
+	var venue models.Venue
+	err = r.collection.FindOne(ctx, bson.M{"_id": venueID}).Decode(&venue)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("venue not found")
+		}
+		return nil, err
+	}
+
+	return &venue, nil
+}
+
+func (r *venueRepo) FindAll(ctx context.Context) ([]models.Venue, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var venues []models.Venue
+	if err := cursor.All(ctx, &venues); err != nil {
+		return nil, err
+	}
+
+	return venues, nil
+}
+
+func (r *venueRepo) Replace(ctx context.Context, venue *models.Venue) error {
+	venue.UpdatedAt = time.Now()
+
+	res, err := r.collection.ReplaceOne(ctx, bson.M{"_id": venue.ID}, venue)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.New("venue not found")
+	}
+
+	return nil
+}
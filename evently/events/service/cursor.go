@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"events/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor makes the opaque next_cursor handed to clients.
+func encodeCursor(cursor models.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor from a client. An empty one starts from the
+// first page.
+func decodeCursor(value string) (*models.Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor models.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// decodeEventCursor is decodeCursor for listings keyed on a Mongo _id.
+func decodeEventCursor(value string) (*models.Cursor, error) {
+	cursor, err := decodeCursor(value)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil && !primitive.IsValidObjectID(cursor.ID) {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// trimPage cuts items, which the repository loads one past limit, down
+// to the page and says where the next one starts.
+func trimPage[T any](items []T, limit int64, cursorOf func(T) models.Cursor) ([]T, *models.PageInfo) {
+	info := &models.PageInfo{}
+	if limit <= 0 || int64(len(items)) <= limit {
+		return items, info
+	}
+
+	items = items[:limit]
+	info.HasMore = true
+	info.NextCursor = encodeCursor(cursorOf(items[len(items)-1]))
+	return items, info
+}
@@ -16,55 +16,117 @@ import (
 )
 
 type EventService interface {
-	CreateEvent(ctx context.Context, event *models.Event) (*models.Event, error)
+	CreateEvent(ctx context.Context, event *models.Event, organizerID string) (*models.Event, error)
 	GetEventByID(ctx context.Context, id string) (*models.Event, error)
-	GetAllEvents(page, limit int64) ([]models.Event, error)
-	GetAllUpcomingEvents(ctx context.Context, page, limit int64) ([]models.UpcomingEvent, error)
-	UpdateEvent(ctx context.Context, id string, updates map[string]interface{}) (*models.Event, error)
-	GetCapacityUtilization(ctx context.Context, eventID string, page, limit int64) ([]models.CapacityUtilization, error)
-	GetMostBookedEvents(ctx context.Context, limit int64) ([]models.MostBookedEvent, error)
-	GetMostPopularEvents(ctx context.Context, limit int64) ([]models.MostPopularEvent, error)
-	DeleteEvent(id string) error
+	GetAllEvents(page, limit int64, cursor string) ([]models.Event, *models.PageInfo, error)
+	GetAllUpcomingEvents(ctx context.Context, page, limit int64, cursor string, filter models.UpcomingFilter) ([]models.UpcomingEvent, *models.PageInfo, error)
+	UpdateEvent(ctx context.Context, id string, updates map[string]interface{}, organizerID string) (*models.Event, error)
+	GetCapacityUtilization(ctx context.Context, eventID, organizerID string, page, limit int64, cursor string) ([]models.CapacityUtilization, *models.PageInfo, error)
+	GetMostBookedEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostBookedEvent, error)
+	GetMostPopularEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostPopularEvent, error)
+	GetSeatMap(ctx context.Context, id string) ([]models.SeatStatus, error)
+	WarmCounters(ctx context.Context) (*models.WarmupReport, error)
+	ChangeStatus(ctx context.Context, id, status, organizerID string) (*models.Event, error)
+	DeleteEvent(ctx context.Context, id, organizerID string) error
+	CreateSeries(ctx context.Context, series *models.EventSeries, organizerID string) (*models.SeriesDetail, error)
+	GetSeries(ctx context.Context, id string) (*models.SeriesDetail, error)
+	UpdateSeries(ctx context.Context, id string, changes *models.EventSeries, organizerID string) (*models.SeriesUpdateReport, error)
+	SearchEvents(ctx context.Context, search models.EventSearch) (*models.EventSearchResult, error)
 }
 
 type eventService struct {
 	repo       repository.EventRepository
+	series     repository.SeriesRepository
+	venues     repository.VenueRepository
+	bookings   repository.BookingsRepository
 	redis      *redis.Client
 	redisSeats *redis.Client
 	redisPrice *redis.Client
 }
 
-func NewEventService(r repository.EventRepository, redisClient *redis.Client, redisSeats *redis.Client, redisPrice *redis.Client) EventService {
+func NewEventService(r repository.EventRepository, series repository.SeriesRepository, venues repository.VenueRepository, bookings repository.BookingsRepository, redisClient *redis.Client, redisSeats *redis.Client, redisPrice *redis.Client) EventService {
 	return &eventService{
 		repo:       r,
+		series:     series,
+		venues:     venues,
+		bookings:   bookings,
 		redis:      redisClient,
 		redisSeats: redisSeats,
 		redisPrice: redisPrice,
 	}
 }
 
-func (s *eventService) CreateEvent(ctx context.Context, event *models.Event) (*models.Event, error) {
+// CreateEvent stores a new event. Events created by an organizer are
+// theirs; admins may name the organizer in the body. The event must fit its
+// venue and not overlap anything else booked there.
+func (s *eventService) CreateEvent(ctx context.Context, event *models.Event, organizerID string) (*models.Event, error) {
 
-	err := validate(event)
+	applyTierDefaults(event)
+
+	if organizerID != "" {
+		event.OrganizerID = organizerID
+	}
+
+	event.Category = normalizeLabel(event.Category)
+	event.Tags = normalizeTags(event.Tags)
+
+	venue, err := s.resolveVenue(ctx, event)
 	if err != nil {
 		return nil, err
 	}
 
-	createdEvent, err1 := s.repo.Create(event)
-	if err1 != nil {
-		return nil, err1
+	// new events stay off sale until they are published and sales open
+	if event.Status == "" {
+		event.Status = "draft"
 	}
 
-	keys, _ := s.redis.Keys(ctx, "events:upcoming:*").Result()
-	if len(keys) > 0 {
-		s.redis.Del(ctx, keys...)
+	if err := validate(event); err != nil {
+		return nil, err
 	}
 
+	var createdEvent *models.Event
+	err = withVenueLock(ctx, s.redis, venue.ID.Hex(), func(ctx context.Context) error {
+		if err := s.checkVenue(ctx, event, venue, ""); err != nil {
+			return err
+		}
+
+		created, err := s.repo.Create(event)
+		createdEvent = created
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateUpcoming(ctx)
+
 	seatsKey := "seatsLeft:" + createdEvent.ID.Hex()
 	priceKey := "price:" + createdEvent.ID.Hex()
 	s.redisSeats.Set(ctx, seatsKey, createdEvent.AvailableSeats, 0)
 	s.redisPrice.Set(ctx, priceKey, createdEvent.Price, 0)
 
+	for _, tier := range createdEvent.Tiers {
+		s.redisSeats.Set(ctx, tierSeatsKey(createdEvent.ID.Hex(), tier.Name), tier.AvailableSeats, 0)
+		s.redisPrice.Set(ctx, tierPriceKey(createdEvent.ID.Hex(), tier.Name), tier.Price, 0)
+	}
+	if len(createdEvent.Tiers) > 0 {
+		s.redisSeats.Set(ctx, tieredKey(createdEvent.ID.Hex()), 1, 0)
+	}
+
+	s.cacheRefundPolicy(ctx, createdEvent)
+	s.cacheSalesWindow(ctx, createdEvent)
+
+	if createdEvent.SeatMap != nil {
+		freeKey := "seatsFree:" + createdEvent.ID.Hex()
+		ids := seatIDs(createdEvent.SeatMap)
+		members := make([]interface{}, len(ids))
+		for i, id := range ids {
+			members[i] = id
+		}
+		s.redisSeats.SAdd(ctx, freeKey, members...)
+		s.redisSeats.Set(ctx, seatMapKey(createdEvent.ID.Hex()), 1, 0)
+	}
+
 	return createdEvent, nil
 }
 
@@ -97,11 +159,38 @@ func (s *eventService) getEventFromCache(ctx context.Context, id string) (*model
 		ev.AvailableSeats= int64(availableSeats)
 	}
 
+	s.applyLiveTierSeats(ctx, &ev)
+
 	s.redis.Expire(ctx, cacheKey, 10*time.Minute)
 	return &ev, nil
 
 }
 
+// applyLiveTierSeats replaces the cached tier seat counts with the live
+// counters the booking consumer decrements.
+func (s *eventService) applyLiveTierSeats(ctx context.Context, ev *models.Event) {
+	if len(ev.Tiers) == 0 {
+		return
+	}
+
+	keys := make([]string, len(ev.Tiers))
+	for i, tier := range ev.Tiers {
+		keys[i] = tierSeatsKey(ev.ID.Hex(), tier.Name)
+	}
+
+	vals, err := s.redisSeats.MGet(ctx, keys...).Result()
+	if err != nil {
+		return
+	}
+
+	for i, val := range vals {
+		if str, ok := val.(string); ok {
+			seats, _ := strconv.ParseInt(str, 10, 64)
+			ev.Tiers[i].AvailableSeats = seats
+		}
+	}
+}
+
 func (s *eventService) GetEventByID(ctx context.Context, id string) (*models.Event, error) {
 	cacheKey := "event:" + id
 
@@ -122,8 +211,21 @@ func (s *eventService) GetEventByID(ctx context.Context, id string) (*models.Eve
 	return event, nil
 }
 
-func (s *eventService) GetAllEvents(page, limit int64) ([]models.Event, error) {
-	return s.repo.FindAll(page, limit)
+func (s *eventService) GetAllEvents(page, limit int64, cursor string) ([]models.Event, *models.PageInfo, error) {
+	after, err := decodeEventCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, err := s.repo.FindAll(page, limit, after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, info := trimPage(events, limit, func(ev models.Event) models.Cursor {
+		return models.Cursor{Time: ev.CreatedAt, ID: ev.ID.Hex()}
+	})
+	return events, info, nil
 }
 
 func (s *eventService) getUpcomingEventsFromCache(ctx context.Context, cacheKey string) ([]models.UpcomingEvent, error) {
@@ -180,24 +282,40 @@ func (s *eventService) getUpcomingEventsFromCache(ctx context.Context, cacheKey
 	return events, nil
 }
 
-func (s *eventService) GetAllUpcomingEvents(ctx context.Context, page, limit int64) ([]models.UpcomingEvent, error) {
+func (s *eventService) GetAllUpcomingEvents(ctx context.Context, page, limit int64, cursor string, filter models.UpcomingFilter) ([]models.UpcomingEvent, *models.PageInfo, error) {
+	after, err := decodeEventCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filter.Category = normalizeLabel(filter.Category)
+	filter.Tag = normalizeLabel(filter.Tag)
+
 	today := time.Now().Format("2006-01-02")
 	cacheKey := fmt.Sprintf("events:upcoming:%s:page=%d:limit=%d", today, page, limit)
-
-	cachedEvents, err := s.getUpcomingEventsFromCache(ctx, cacheKey)
-	if err == nil {
-		return cachedEvents, nil
+	if after != nil {
+		cacheKey = fmt.Sprintf("events:upcoming:%s:cursor=%s:limit=%d", today, cursor, limit)
+	}
+	if filter.Category != "" || filter.Tag != "" {
+		cacheKey += fmt.Sprintf(":category=%s:tag=%s", filter.Category, filter.Tag)
 	}
 
-	events, err := s.repo.FindAllUpcomingEvents(page, limit)
+	// pages are cached with the extra event that says whether more follow
+	events, err := s.getUpcomingEventsFromCache(ctx, cacheKey)
 	if err != nil {
-		return nil, err
-	}
+		events, err = s.repo.FindAllUpcomingEvents(page, limit, after, filter)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	data, _ := json.Marshal(events)
-	s.redis.Set(ctx, cacheKey, data, 5*time.Minute)
+		data, _ := json.Marshal(events)
+		s.redis.Set(ctx, cacheKey, data, 5*time.Minute)
+	}
 
-	return events, nil
+	events, info := trimPage(events, limit, func(ev models.UpcomingEvent) models.Cursor {
+		return models.Cursor{Time: ev.Date, ID: ev.ID.Hex()}
+	})
+	return events, info, nil
 }
 
 func (s *eventService) updateCache(ctx context.Context, id string, updates map[string]interface{}) {
@@ -213,6 +331,8 @@ func (s *eventService) updateCache(ctx context.Context, id string, updates map[s
 		"date":          true,
 		"total_seats": true,
 		"price":         true,
+		"category":      true,
+		"tags":          true,
 	}
 
 	if _, exists := updates["price"]; exists {
@@ -236,12 +356,93 @@ func (s *eventService) updateCache(ctx context.Context, id string, updates map[s
 	}
 }
 
-func (s *eventService) UpdateEvent(ctx context.Context, id string, updates map[string]interface{}) (*models.Event, error) {
+func (s *eventService) UpdateEvent(ctx context.Context, id string, updates map[string]interface{}, organizerID string) (*models.Event, error) {
+
+	current, err := s.ownedEvent(id, organizerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, reassigned := updates["organizer_id"]; reassigned && organizerID != "" {
+		return nil, errors.New("only admins can change an event's organizer")
+	}
 
 	if err := validateUpdates(updates); err != nil {
 		return nil, err
 	}
 
+	// an occurrence edited on its own no longer follows its series. Its
+	// date is its slot in the series, so it cannot move.
+	if current.SeriesID != "" {
+		if _, moved := updates["date"]; moved {
+			return nil, errors.New("an occurrence of a series cannot be moved, add an exception to the series instead")
+		}
+		updates["series_overridden"] = true
+	}
+
+	return s.applyUpdates(ctx, current, updates)
+}
+
+// applyUpdates stores validated updates to an event and refreshes whatever
+// Redis keeps of it. Updates that move the event in its venue's calendar
+// hold the lock of the venue it ends up at.
+func (s *eventService) applyUpdates(ctx context.Context, current *models.Event, updates map[string]interface{}) (*models.Event, error) {
+	_, venueChanged := updates["venue_id"]
+	_, durationChanged := updates["duration_minutes"]
+	_, seatsChanged := updates["total_seats"]
+	_, dateChanged := updates["date"]
+	if !venueChanged && !durationChanged && !seatsChanged && !dateChanged {
+		return s.storeUpdates(ctx, current, updates)
+	}
+
+	venueID := current.VenueID
+	if id, ok := updates["venue_id"].(string); ok {
+		venueID = id
+	}
+
+	var updated *models.Event
+	err := withVenueLock(ctx, s.redis, venueID, func(ctx context.Context) error {
+		var err error
+		updated, err = s.storeUpdates(ctx, current, updates)
+		return err
+	})
+	return updated, err
+}
+
+func (s *eventService) storeUpdates(ctx context.Context, current *models.Event, updates map[string]interface{}) (*models.Event, error) {
+	id := current.ID.Hex()
+
+	_, startChanged := updates["sales_start"]
+	_, endChanged := updates["sales_end"]
+	_, dateChanged := updates["date"]
+	windowChanged := startChanged || endChanged || dateChanged
+
+	_, venueChanged := updates["venue_id"]
+	_, durationChanged := updates["duration_minutes"]
+	_, seatsChanged := updates["total_seats"]
+	if venueChanged || durationChanged || seatsChanged || dateChanged {
+		if err := s.checkUpdatedVenue(ctx, current, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	if windowChanged {
+		start, end, date := current.SalesStart, current.SalesEnd, current.Date
+		if t, ok := updates["sales_start"].(time.Time); ok {
+			start = &t
+		}
+		if t, ok := updates["sales_end"].(time.Time); ok {
+			end = &t
+		}
+		if t, ok := updates["date"].(time.Time); ok {
+			date = t
+		}
+
+		if err := validateSalesWindow(start, end, date); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.repo.UpdateFields(id, updates); err != nil {
 		return nil, err
 	}
@@ -253,23 +454,145 @@ func (s *eventService) UpdateEvent(ctx context.Context, id string, updates map[s
 
 	s.updateCache(ctx, id, updates)
 
+	_, policyChanged := updates["refund_policy"]
+	if policyChanged || dateChanged {
+		s.cacheRefundPolicy(ctx, updatedEvent)
+	}
+
+	if windowChanged {
+		s.cacheSalesWindow(ctx, updatedEvent)
+	}
+
 	return updatedEvent, nil
 }
 
-func (s *eventService) GetCapacityUtilization(ctx context.Context, eventID string, page, limit int64) ([]models.CapacityUtilization, error) {
-	return s.repo.GetCapacityUtilization(ctx, eventID, page, limit)
+// cacheRefundPolicy publishes the event's refund policy next to its price
+// so the cancel consumer can work out refunds without reading Mongo.
+func (s *eventService) cacheRefundPolicy(ctx context.Context, event *models.Event) {
+	policyKey := "refundPolicy:" + event.ID.Hex()
+
+	if event.RefundPolicy == nil {
+		s.redisPrice.Del(ctx, policyKey)
+		return
+	}
+
+	data, err := refundPolicyData(event)
+	if err != nil {
+		return
+	}
+
+	s.redisPrice.Set(ctx, policyKey, data, 0)
+}
+
+func refundPolicyData(event *models.Event) ([]byte, error) {
+	return json.Marshal(struct {
+		EventDate time.Time           `json:"event_date"`
+		Rules     []models.RefundRule `json:"rules"`
+	}{event.Date, event.RefundPolicy.Rules})
+}
+
+func (s *eventService) GetCapacityUtilization(ctx context.Context, eventID, organizerID string, page, limit int64, cursor string) ([]models.CapacityUtilization, *models.PageInfo, error) {
+	after, err := decodeEventCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := s.repo.GetCapacityUtilization(ctx, eventID, organizerID, page, limit, after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, info := trimPage(rows, limit, func(row models.CapacityUtilization) models.Cursor {
+		return models.Cursor{Value: row.CapacityUtilization, ID: row.EventID}
+	})
+	return rows, info, nil
+}
+
+func (s *eventService) GetMostBookedEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostBookedEvent, error) {
+	return s.repo.GetMostBookedEvents(ctx, organizerID, limit)
 }
 
-func (s *eventService) GetMostBookedEvents(ctx context.Context, limit int64) ([]models.MostBookedEvent, error) {
-	return s.repo.GetMostBookedEvents(ctx, limit)
+func (s *eventService) GetMostPopularEvents(ctx context.Context, organizerID string, limit int64) ([]models.MostPopularEvent, error) {
+	return s.repo.GetMostPopularEvents(ctx, organizerID, limit)
+}
+
+func (s *eventService) GetSeatMap(ctx context.Context, id string) ([]models.SeatStatus, error) {
+	event, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.SeatMap == nil {
+		return nil, errors.New("event has no seat map")
+	}
+
+	free, err := s.redisSeats.SMembers(ctx, "seatsFree:"+id).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	freeSet := make(map[string]bool, len(free))
+	for _, seatID := range free {
+		freeSet[seatID] = true
+	}
+
+	var seats []models.SeatStatus
+	for _, section := range event.SeatMap.Sections {
+		for _, row := range section.Rows {
+			for _, number := range row.Seats {
+				sid := seatID(section.Name, row.Name, number)
+				seats = append(seats, models.SeatStatus{
+					SeatID:    sid,
+					Section:   section.Name,
+					Row:       row.Name,
+					Number:    number,
+					Available: freeSet[sid],
+				})
+			}
+		}
+	}
+
+	return seats, nil
 }
 
-func (s *eventService) GetMostPopularEvents(ctx context.Context, limit int64) ([]models.MostPopularEvent, error) {
-	return s.repo.GetMostPopularEvents(ctx, limit)
+// DeleteEvent removes a draft event along with its Redis keys. Anything
+// further along may have bookings, so it has to be cancelled instead.
+func (s *eventService) DeleteEvent(ctx context.Context, id, organizerID string) error {
+	event, err := s.ownedEvent(id, organizerID)
+	if err != nil {
+		return err
+	}
+
+	if eventStatus(event) != "draft" {
+		return errors.New("only draft events can be deleted, cancel the event instead")
+	}
+
+	if err := s.ensureNoBookings(ctx, id); err != nil {
+		return err
+	}
+
+	return s.removeEvent(ctx, event)
 }
 
-func (s *eventService) DeleteEvent(id string) error {
-	return s.repo.Delete(id)
+// removeEvent deletes an event along with its seat and price keys.
+func (s *eventService) removeEvent(ctx context.Context, event *models.Event) error {
+	id := event.ID.Hex()
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	seatKeys := []string{"seatsLeft:" + id, "seatsFree:" + id, seatMapKey(id), tieredKey(id)}
+	priceKeys := []string{"price:" + id, "refundPolicy:" + id, "sales:" + id}
+	for _, tier := range event.Tiers {
+		seatKeys = append(seatKeys, tierSeatsKey(id, tier.Name))
+		priceKeys = append(priceKeys, tierPriceKey(id, tier.Name))
+	}
+	s.redisSeats.Del(ctx, seatKeys...)
+	s.redisPrice.Del(ctx, priceKeys...)
+	s.redis.Del(ctx, "event:"+id)
+
+	return nil
 }
 
 func validate(e *models.Event) error {
@@ -286,6 +609,10 @@ func validate(e *models.Event) error {
 		return errors.New("date is required and must be in the future")
 	}
 
+	if e.Duration <= 0 {
+		return errors.New("duration_minutes must be greater than 0")
+	}
+
 	if e.Price <= 0 {
 		return errors.New("price must be greater than 0")
 	}
@@ -298,6 +625,121 @@ func validate(e *models.Event) error {
 		return errors.New("total seats must be >= available seats")
 	}
 
+	switch e.Status {
+	case "draft", "published", "sales_open":
+	default:
+		return errors.New("status must be draft, published or sales_open when an event is created")
+	}
+
+	if err := validateSalesWindow(e.SalesStart, e.SalesEnd, e.Date); err != nil {
+		return err
+	}
+
+	if e.RefundPolicy != nil {
+		if err := validateRefundPolicy(e.RefundPolicy); err != nil {
+			return err
+		}
+	}
+
+	if e.PricingRules != nil {
+		if err := validatePricingRules(e.PricingRules); err != nil {
+			return err
+		}
+	}
+
+	if len(e.Tiers) > 0 {
+		if err := validateTiers(e); err != nil {
+			return err
+		}
+	}
+
+	if e.SeatMap != nil {
+		if err := validateSeatMap(e.SeatMap); err != nil {
+			return err
+		}
+
+		if int64(len(seatIDs(e.SeatMap))) != e.TotalSeats || e.AvailableSeats != e.TotalSeats {
+			return errors.New("seat map must contain exactly total_seats seats, all available")
+		}
+	}
+
+	return nil
+}
+
+// seatID builds the identifier bookings use to claim a seat, e.g. "A-3-12".
+func seatID(section, row, number string) string {
+	return section + "-" + row + "-" + number
+}
+
+// seatMapKey marks an event with a seat map for the bookings consumer, which
+// then only takes seats picked by id. seatsFree:<id> cannot tell it, as the
+// set disappears once every seat is taken.
+func seatMapKey(eventID string) string {
+	return "seatMap:" + eventID
+}
+
+func seatIDs(m *models.SeatMap) []string {
+	var ids []string
+	for _, section := range m.Sections {
+		for _, row := range section.Rows {
+			for _, number := range row.Seats {
+				ids = append(ids, seatID(section.Name, row.Name, number))
+			}
+		}
+	}
+	return ids
+}
+
+func validateRefundPolicy(p *models.RefundPolicy) error {
+	seen := make(map[int64]bool)
+	for _, rule := range p.Rules {
+		if rule.DaysBefore < 0 {
+			return errors.New("refund rule days_before must be >= 0")
+		}
+
+		if rule.Percent < 0 || rule.Percent > 100 {
+			return errors.New("refund rule percent must be between 0 and 100")
+		}
+
+		if seen[rule.DaysBefore] {
+			return fmt.Errorf("duplicate refund rule for days_before %d", rule.DaysBefore)
+		}
+		seen[rule.DaysBefore] = true
+	}
+
+	return nil
+}
+
+func validateSeatMap(m *models.SeatMap) error {
+	if len(m.Sections) == 0 {
+		return errors.New("seat map must have at least one section")
+	}
+
+	seen := make(map[string]bool)
+	for _, section := range m.Sections {
+		if strings.TrimSpace(section.Name) == "" {
+			return errors.New("seat map section name is required")
+		}
+
+		for _, row := range section.Rows {
+			if strings.TrimSpace(row.Name) == "" {
+				return fmt.Errorf("row name is required in section %s", section.Name)
+			}
+
+			for _, number := range row.Seats {
+				if strings.TrimSpace(number) == "" {
+					return fmt.Errorf("seat number is required in section %s row %s", section.Name, row.Name)
+				}
+
+				id := seatID(section.Name, row.Name, number)
+				if seen[id] {
+					return fmt.Errorf("duplicate seat %s", id)
+				}
+				seen[id] = true
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -307,7 +749,7 @@ func validateUpdates(updates map[string]interface{}) error {
 
 		switch key {
 
-		case "title", "venue":
+		case "title":
 			strVal, ok := value.(string)
 			if !ok || strings.TrimSpace(strVal) == "" {
 				return fmt.Errorf("%s must be a non-empty string", key)
@@ -330,6 +772,23 @@ func validateUpdates(updates map[string]interface{}) error {
 
 			updates[key] = parsed
 
+		case "venue_id":
+			str, ok := value.(string)
+			if !ok || strings.TrimSpace(str) == "" {
+				return fmt.Errorf("venue_id must be a non-empty string")
+			}
+
+		case "venue":
+			return fmt.Errorf("venue is set from the venue, change venue_id instead")
+
+		case "duration_minutes":
+			minutes, ok := value.(float64)
+			if !ok || minutes <= 0 || minutes != float64(int64(minutes)) {
+				return fmt.Errorf("duration_minutes must be a positive whole number")
+			}
+
+			updates[key] = int64(minutes)
+
 		case "price":
 			price, ok := value.(float64)
 			if !ok || price <= 0 {
@@ -343,6 +802,96 @@ func validateUpdates(updates map[string]interface{}) error {
 			}
 
 			updates[key] = int(seats)
+
+		case "refund_policy":
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("invalid refund_policy")
+			}
+
+			var policy models.RefundPolicy
+			if err := json.Unmarshal(data, &policy); err != nil {
+				return fmt.Errorf("invalid refund_policy")
+			}
+
+			if err := validateRefundPolicy(&policy); err != nil {
+				return err
+			}
+
+			updates[key] = policy
+
+		case "pricing_rules":
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("invalid pricing_rules")
+			}
+
+			var rules models.PricingRules
+			if err := json.Unmarshal(data, &rules); err != nil {
+				return fmt.Errorf("invalid pricing_rules")
+			}
+
+			if err := validatePricingRules(&rules); err != nil {
+				return err
+			}
+
+			updates[key] = rules
+
+		case "sales_start", "sales_end":
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s must be a string (ISO format)", key)
+			}
+
+			parsed, err := time.Parse(time.RFC3339, str)
+			if err != nil {
+				return fmt.Errorf("invalid %s format, must be RFC3339", key)
+			}
+
+			updates[key] = parsed
+
+		case "category":
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("category must be a string")
+			}
+
+			updates[key] = normalizeLabel(str)
+
+		case "tags":
+			list, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("tags must be a list of strings")
+			}
+
+			tags := make([]string, 0, len(list))
+			for _, item := range list {
+				tag, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("tags must be a list of strings")
+				}
+				tags = append(tags, tag)
+			}
+
+			updates[key] = normalizeTags(tags)
+
+		case "organizer_id":
+			str, ok := value.(string)
+			if !ok || strings.TrimSpace(str) == "" {
+				return fmt.Errorf("organizer_id must be a non-empty string")
+			}
+
+		case "series_id", "series_overridden":
+			return fmt.Errorf("%s is managed through the event's series", key)
+
+		case "status":
+			return fmt.Errorf("status is changed through POST /events/:id/status")
+
+		case "seat_map":
+			return fmt.Errorf("seat_map cannot be changed after the event is created")
+
+		case "tiers":
+			return fmt.Errorf("tiers cannot be changed after the event is created")
 		}
 	}
 	return nil
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"events/models"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// eventTransitions lists where each lifecycle status can go. Only a
+// sales_open event can be booked, and only while inside its sales window;
+// published events are visible but not on sale yet. Going back to draft is
+// only possible before anyone booked; after that the event has to be
+// cancelled. sold_out is set by hand: the bookings consumer already refuses
+// requests once seatsLeft runs out, so nothing moves an event there or back
+// when the seats do. Cancelled is final.
+var eventTransitions = map[string][]string{
+	"draft":      {"published", "cancelled"},
+	"published":  {"draft", "sales_open", "cancelled"},
+	"sales_open": {"published", "sold_out", "cancelled"},
+	"sold_out":   {"sales_open", "cancelled"},
+	"cancelled":  {},
+}
+
+// eventStatus is the event's lifecycle status. Events created before they
+// had one were bookable straight away, so they count as sales_open.
+func eventStatus(event *models.Event) string {
+	if event.Status == "" {
+		return "sales_open"
+	}
+	return event.Status
+}
+
+// ChangeStatus moves an event through its lifecycle. Cancelling it queues
+// the cancellation and refund of all its bookings; cancelling an event that
+// already is cancelled queues that again, in case it failed the first time.
+func (s *eventService) ChangeStatus(ctx context.Context, id, status, organizerID string) (*models.Event, error) {
+	event, err := s.ownedEvent(id, organizerID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := eventStatus(event)
+	if _, known := eventTransitions[status]; !known {
+		return nil, fmt.Errorf("unknown status %q", status)
+	}
+
+	retryCancel := from == "cancelled" && status == "cancelled"
+	if !retryCancel && !slices.Contains(eventTransitions[from], status) {
+		return nil, fmt.Errorf("event cannot go from %s to %s", from, status)
+	}
+
+	if status == "sales_open" && !event.Date.After(time.Now()) {
+		return nil, errors.New("sales cannot open for an event that has already happened")
+	}
+
+	if status == "draft" {
+		if err := s.ensureNoBookings(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if !retryCancel {
+		if err := s.repo.UpdateStatus(ctx, id, event.Status, status); err != nil {
+			return nil, err
+		}
+		event.Status = status
+	}
+
+	s.redis.Del(ctx, "event:"+id)
+	s.invalidateUpcoming(ctx)
+	s.cacheSalesWindow(ctx, event)
+
+	if status == "cancelled" {
+		topic, ok := os.LookupEnv("TOPIC_EVENT_CANCELLATIONS")
+		if !ok || topic == "" {
+			return nil, errors.New("TOPIC_EVENT_CANCELLATIONS is not set, bookings were not cancelled")
+		}
+		if err := s.bookings.QueueEventCancellation(ctx, topic, id); err != nil {
+			return nil, fmt.Errorf("event cancelled but its bookings were not: %w", err)
+		}
+	}
+
+	return event, nil
+}
+
+// ensureNoBookings refuses to take an event back to draft, or delete it,
+// once it has bookings; those go through cancellation and its refunds.
+func (s *eventService) ensureNoBookings(ctx context.Context, id string) error {
+	booked, err := s.bookings.HasBookings(ctx, id)
+	if err != nil {
+		return err
+	}
+	if booked {
+		return errors.New("event has bookings, cancel the event instead")
+	}
+	return nil
+}
+
+func (s *eventService) invalidateUpcoming(ctx context.Context) {
+	keys, _ := s.redis.Keys(ctx, "events:upcoming:*").Result()
+	if len(keys) > 0 {
+		s.redis.Del(ctx, keys...)
+	}
+}
+
+// cacheSalesWindow publishes the event's status and sales window under
+// sales:<eventId> next to its price, for the bookings consumer to check
+// before it takes any seats.
+func (s *eventService) cacheSalesWindow(ctx context.Context, event *models.Event) {
+	data, err := salesWindowData(event)
+	if err != nil {
+		return
+	}
+	s.redisPrice.Set(ctx, "sales:"+event.ID.Hex(), data, 0)
+}
+
+func salesWindowData(event *models.Event) ([]byte, error) {
+	return json.Marshal(struct {
+		Status     string     `json:"status"`
+		SalesStart *time.Time `json:"sales_start,omitempty"`
+		SalesEnd   *time.Time `json:"sales_end,omitempty"`
+	}{eventStatus(event), event.SalesStart, event.SalesEnd})
+}
+
+// validateSalesWindow checks that sales end after they start and no later
+// than the event itself. Either end of the window may be left open.
+func validateSalesWindow(start, end *time.Time, date time.Time) error {
+	if start != nil && end != nil && !end.After(*start) {
+		return errors.New("sales_end must be after sales_start")
+	}
+
+	if end != nil && end.After(date) {
+		return errors.New("sales_end cannot be after the event date")
+	}
+
+	if start != nil && !start.Before(date) {
+		return errors.New("sales_start must be before the event date")
+	}
+
+	return nil
+}
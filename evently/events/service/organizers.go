@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"events/models"
+	"strings"
+)
+
+var ErrNotOwner = errors.New("event belongs to another organizer")
+
+// ownedEvent loads the event an organizer wants to manage and refuses
+// events that are not theirs. An empty organizerID is an admin, who may
+// manage any event.
+func (s *eventService) ownedEvent(id, organizerID string) (*models.Event, error) {
+	event, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if organizerID != "" && event.OrganizerID != organizerID {
+		return nil, ErrNotOwner
+	}
+
+	return event, nil
+}
+
+// normalizeLabel lowercases and trims a category or tag, so filters match
+// however it was typed.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// normalizeTags normalizes tags and drops empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = normalizeLabel(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"events/repository"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type PricingService interface {
+	ApplyPricing(ctx context.Context) (int, error)
+	GetPriceHistory(ctx context.Context, eventID string, page, limit int64) ([]models.PriceChange, error)
+}
+
+type pricingService struct {
+	events     repository.EventRepository
+	history    repository.PriceHistoryRepository
+	redisSeats *redis.Client
+	redisPrice *redis.Client
+}
+
+func NewPricingService(events repository.EventRepository, history repository.PriceHistoryRepository, redisSeats *redis.Client, redisPrice *redis.Client) PricingService {
+	return &pricingService{
+		events:     events,
+		history:    history,
+		redisSeats: redisSeats,
+		redisPrice: redisPrice,
+	}
+}
+
+// ApplyPricing reprices every upcoming event with pricing rules from its
+// live occupancy and the days left, rewriting the price: keys the booking
+// consumer charges from. It returns how many prices changed.
+func (s *pricingService) ApplyPricing(ctx context.Context) (int, error) {
+	events, err := s.events.FindUpcomingPriced(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	changed := 0
+	for i := range events {
+		ev := &events[i]
+		id := ev.ID.Hex()
+
+		occupancy := s.occupancy(ctx, ev)
+		daysLeft := int64(ev.Date.Sub(now).Hours() / 24)
+		multiplier := priceMultiplier(ev.PricingRules, occupancy, daysLeft)
+
+		change := models.PriceChange{EventID: id, Multiplier: multiplier, Occupancy: occupancy, DaysLeft: daysLeft}
+
+		ok, err := s.setPrice(ctx, "price:"+id, ev.Price*multiplier, change)
+		if err != nil {
+			return changed, err
+		}
+		if ok {
+			changed++
+		}
+
+		for _, tier := range ev.Tiers {
+			change.Tier = tier.Name
+			ok, err := s.setPrice(ctx, tierPriceKey(id, tier.Name), tier.Price*multiplier, change)
+			if err != nil {
+				return changed, err
+			}
+			if ok {
+				changed++
+			}
+		}
+	}
+
+	log.Printf("Dynamic pricing checked %d events, changed %d prices", len(events), changed)
+	return changed, nil
+}
+
+// setPrice writes a new price and records it in the price history when it
+// differs from what is currently charged.
+func (s *pricingService) setPrice(ctx context.Context, key string, price float64, change models.PriceChange) (bool, error) {
+	price = math.Round(price*100) / 100
+
+	current, err := s.redisPrice.Get(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+
+	old, _ := strconv.ParseFloat(current, 64)
+	if err == nil && old == price {
+		return false, nil
+	}
+
+	if err := s.redisPrice.Set(ctx, key, price, 0).Err(); err != nil {
+		return false, err
+	}
+
+	change.OldPrice = old
+	change.Price = price
+	change.ChangedAt = time.Now()
+	if err := s.history.Record(ctx, &change); err != nil {
+		log.Printf("Failed to record price change for event %s: %v", change.EventID, err)
+	}
+
+	return true, nil
+}
+
+// occupancy is the share of seats sold, in percent, from the live counter
+// and from Mongo if the counter is missing.
+func (s *pricingService) occupancy(ctx context.Context, ev *models.Event) float64 {
+	if ev.TotalSeats <= 0 {
+		return 0
+	}
+
+	left := ev.AvailableSeats
+	if val, err := s.redisSeats.Get(ctx, "seatsLeft:"+ev.ID.Hex()).Result(); err == nil {
+		left, _ = strconv.ParseInt(val, 10, 64)
+	}
+
+	return math.Round(float64(ev.TotalSeats-left)/float64(ev.TotalSeats)*10000) / 100
+}
+
+func (s *pricingService) GetPriceHistory(ctx context.Context, eventID string, page, limit int64) ([]models.PriceChange, error) {
+	return s.history.FindByEvent(ctx, eventID, page, limit)
+}
+
+func priceMultiplier(rules *models.PricingRules, occupancy float64, daysLeft int64) float64 {
+	if rules == nil {
+		return 1
+	}
+
+	occupancyMultiplier := 1.0
+	bestOccupancy := -1.0
+	for _, band := range rules.Occupancy {
+		if occupancy >= band.MinOccupancy && band.MinOccupancy > bestOccupancy {
+			bestOccupancy = band.MinOccupancy
+			occupancyMultiplier = band.Multiplier
+		}
+	}
+
+	timeMultiplier := 1.0
+	bestDays := int64(-1)
+	for _, band := range rules.Time {
+		if daysLeft <= band.WithinDays && (bestDays < 0 || band.WithinDays < bestDays) {
+			bestDays = band.WithinDays
+			timeMultiplier = band.Multiplier
+		}
+	}
+
+	return occupancyMultiplier * timeMultiplier
+}
+
+func validatePricingRules(r *models.PricingRules) error {
+	seenOccupancy := make(map[float64]bool)
+	for _, band := range r.Occupancy {
+		if band.MinOccupancy < 0 || band.MinOccupancy > 100 {
+			return errors.New("pricing min_occupancy must be between 0 and 100")
+		}
+
+		if band.Multiplier <= 0 {
+			return errors.New("pricing multiplier must be greater than 0")
+		}
+
+		if seenOccupancy[band.MinOccupancy] {
+			return fmt.Errorf("duplicate occupancy band for min_occupancy %.2f", band.MinOccupancy)
+		}
+		seenOccupancy[band.MinOccupancy] = true
+	}
+
+	seenDays := make(map[int64]bool)
+	for _, band := range r.Time {
+		if band.WithinDays < 0 {
+			return errors.New("pricing within_days must be >= 0")
+		}
+
+		if band.Multiplier <= 0 {
+			return errors.New("pricing multiplier must be greater than 0")
+		}
+
+		if seenDays[band.WithinDays] {
+			return fmt.Errorf("duplicate time band for within_days %d", band.WithinDays)
+		}
+		seenDays[band.WithinDays] = true
+	}
+
+	return nil
+}
+
+// StartPricingScheduler runs ApplyPricing every interval until ctx is
+// cancelled.
+func StartPricingScheduler(ctx context.Context, svc PricingService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.ApplyPricing(ctx); err != nil {
+				log.Println("Dynamic pricing failed:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"events/models"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPriceMultiplier(t *testing.T) {
+	rules := &models.PricingRules{
+		Occupancy: []models.OccupancyBand{
+			{MinOccupancy: 80, Multiplier: 1.5},
+			{MinOccupancy: 50, Multiplier: 1.2},
+			{MinOccupancy: 0, Multiplier: 0.9},
+		},
+		Time: []models.TimeBand{
+			{WithinDays: 7, Multiplier: 1.1},
+			{WithinDays: 3, Multiplier: 1.2},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		rules     *models.PricingRules
+		occupancy float64
+		daysLeft  int64
+		want      float64
+	}{
+		{"no rules", nil, 95, 0, 1},
+		{"lowest band far out", rules, 10, 30, 0.9},
+		{"band starts at its minimum", rules, 50, 30, 1.2},
+		{"highest band reached wins", rules, 85, 30, 1.5},
+		{"widest time band", rules, 10, 7, 0.9 * 1.1},
+		{"narrowest time band covering the days wins", rules, 10, 2, 0.9 * 1.2},
+		{"bands combine", rules, 80, 3, 1.5 * 1.2},
+		{"no occupancy band reached", &models.PricingRules{Occupancy: []models.OccupancyBand{{MinOccupancy: 50, Multiplier: 2}}}, 20, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := priceMultiplier(tt.rules, tt.occupancy, tt.daysLeft)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("priceMultiplier(%v, %d) = %v, want %v", tt.occupancy, tt.daysLeft, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePricingRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   models.PricingRules
+		wantErr string
+	}{
+		{"empty", models.PricingRules{}, ""},
+		{"valid", models.PricingRules{
+			Occupancy: []models.OccupancyBand{{MinOccupancy: 0, Multiplier: 0.8}, {MinOccupancy: 100, Multiplier: 2}},
+			Time:      []models.TimeBand{{WithinDays: 0, Multiplier: 1.5}, {WithinDays: 14, Multiplier: 1.1}},
+		}, ""},
+		{"occupancy below 0", models.PricingRules{Occupancy: []models.OccupancyBand{{MinOccupancy: -1, Multiplier: 1}}}, "min_occupancy"},
+		{"occupancy above 100", models.PricingRules{Occupancy: []models.OccupancyBand{{MinOccupancy: 101, Multiplier: 1}}}, "min_occupancy"},
+		{"zero occupancy multiplier", models.PricingRules{Occupancy: []models.OccupancyBand{{MinOccupancy: 50, Multiplier: 0}}}, "multiplier"},
+		{"duplicate occupancy band", models.PricingRules{Occupancy: []models.OccupancyBand{{MinOccupancy: 50, Multiplier: 1.2}, {MinOccupancy: 50, Multiplier: 1.4}}}, "duplicate occupancy"},
+		{"negative days", models.PricingRules{Time: []models.TimeBand{{WithinDays: -1, Multiplier: 1}}}, "within_days"},
+		{"negative time multiplier", models.PricingRules{Time: []models.TimeBand{{WithinDays: 3, Multiplier: -1}}}, "multiplier"},
+		{"duplicate time band", models.PricingRules{Time: []models.TimeBand{{WithinDays: 3, Multiplier: 1.2}, {WithinDays: 3, Multiplier: 1.3}}}, "duplicate time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePricingRules(&tt.rules)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
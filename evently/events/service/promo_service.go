@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"events/models"
+	"events/repository"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The bookings consumer redeems codes against the price Redis: it reads the
+// definition from promo:<CODE> and counts uses in promoUses:<CODE> and
+// promoUserUses:<CODE>:<userId>.
+const (
+	promoKeyPrefix     = "promo:"
+	promoUsesKeyPrefix = "promoUses:"
+)
+
+type PromoService interface {
+	CreatePromo(ctx context.Context, promo *models.PromoCode) (*models.PromoCode, error)
+	GetPromo(ctx context.Context, code string) (*models.PromoCode, error)
+	GetAllPromos(ctx context.Context) ([]models.PromoCode, error)
+	UpdatePromo(ctx context.Context, code string, promo *models.PromoCode) (*models.PromoCode, error)
+	DeletePromo(ctx context.Context, code string) error
+	CachePromos(ctx context.Context) error
+}
+
+type promoService struct {
+	repo       repository.PromoRepository
+	redisPrice *redis.Client
+}
+
+func NewPromoService(r repository.PromoRepository, redisPrice *redis.Client) PromoService {
+	return &promoService{
+		repo:       r,
+		redisPrice: redisPrice,
+	}
+}
+
+func (s *promoService) CreatePromo(ctx context.Context, promo *models.PromoCode) (*models.PromoCode, error) {
+	promo.Code = normalizePromoCode(promo.Code)
+
+	if err := validatePromo(promo); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.Create(ctx, promo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cachePromo(ctx, created)
+	return created, nil
+}
+
+func (s *promoService) GetPromo(ctx context.Context, code string) (*models.PromoCode, error) {
+	promo, err := s.repo.FindByCode(ctx, normalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	promo.Uses = s.uses(ctx, promo.Code)
+	return promo, nil
+}
+
+func (s *promoService) GetAllPromos(ctx context.Context) ([]models.PromoCode, error) {
+	promos, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range promos {
+		promos[i].Uses = s.uses(ctx, promos[i].Code)
+	}
+
+	return promos, nil
+}
+
+func (s *promoService) UpdatePromo(ctx context.Context, code string, promo *models.PromoCode) (*models.PromoCode, error) {
+	promo.Code = normalizePromoCode(code)
+
+	if err := validatePromo(promo); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Replace(ctx, promo.Code, promo); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.FindByCode(ctx, promo.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cachePromo(ctx, updated)
+	updated.Uses = s.uses(ctx, updated.Code)
+	return updated, nil
+}
+
+// DeletePromo removes the code so it can no longer be redeemed. Its usage
+// counters are kept so bookings that already used it still add up.
+func (s *promoService) DeletePromo(ctx context.Context, code string) error {
+	code = normalizePromoCode(code)
+
+	if err := s.repo.Delete(ctx, code); err != nil {
+		return err
+	}
+
+	return s.redisPrice.Del(ctx, promoKeyPrefix+code).Err()
+}
+
+// CachePromos republishes every promo definition from Mongo, e.g. after the
+// price Redis was flushed.
+func (s *promoService) CachePromos(ctx context.Context) error {
+	promos, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range promos {
+		s.cachePromo(ctx, &promos[i])
+	}
+
+	log.Printf("Cached %d promo codes", len(promos))
+	return nil
+}
+
+func (s *promoService) cachePromo(ctx context.Context, promo *models.PromoCode) {
+	data, err := json.Marshal(promo)
+	if err != nil {
+		return
+	}
+
+	s.redisPrice.Set(ctx, promoKeyPrefix+promo.Code, data, 0)
+}
+
+func (s *promoService) uses(ctx context.Context, code string) int64 {
+	val, err := s.redisPrice.Get(ctx, promoUsesKeyPrefix+code).Result()
+	if err != nil {
+		return 0
+	}
+
+	uses, _ := strconv.ParseInt(val, 10, 64)
+	return uses
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validatePromo(p *models.PromoCode) error {
+	if p.Code == "" {
+		return errors.New("code is required")
+	}
+
+	if strings.Contains(p.Code, ":") {
+		return errors.New("code must not contain ':'")
+	}
+
+	switch p.Type {
+	case "percent":
+		if p.Value <= 0 || p.Value > 100 {
+			return errors.New("percent discount must be between 0 and 100")
+		}
+	case "fixed":
+		if p.Value <= 0 {
+			return errors.New("fixed discount must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("type must be percent or fixed")
+	}
+
+	if p.MaxUses < 0 || p.PerUserLimit < 0 {
+		return errors.New("max_uses and per_user_limit must be >= 0")
+	}
+
+	if p.ValidUntil.IsZero() || !p.ValidUntil.After(p.ValidFrom) {
+		return errors.New("valid_until is required and must be after valid_from")
+	}
+
+	return nil
+}
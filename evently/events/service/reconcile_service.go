@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"events/models"
+	"events/repository"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// setIfUnchangedScript only repairs a seat counter that nobody touched since
+// it was read, so a booking landing mid-reconcile is never overwritten.
+var setIfUnchangedScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current == ARGV[1] then
+    redis.call("SET", KEYS[1], ARGV[2])
+    return 1
+end
+return 0
+`)
+
+type ReconcileService interface {
+	Reconcile(ctx context.Context, repair bool) (*models.ReconcileReport, error)
+	LastReport() *models.ReconcileReport
+}
+
+type reconcileService struct {
+	events     repository.EventRepository
+	bookings   repository.BookingsRepository
+	redis      *redis.Client
+	redisSeats *redis.Client
+
+	mu   sync.Mutex
+	last *models.ReconcileReport
+}
+
+func NewReconcileService(events repository.EventRepository, bookings repository.BookingsRepository, redisClient *redis.Client, redisSeats *redis.Client) ReconcileService {
+	return &reconcileService{
+		events:     events,
+		bookings:   bookings,
+		redis:      redisClient,
+		redisSeats: redisSeats,
+	}
+}
+
+// Reconcile compares seatsLeft:<id> in Redis and available_seats in Mongo
+// against total_seats minus the seats Postgres says are sold, and with
+// repair set brings both back in line. Repair is opt-in: requests still
+// being processed and seat updates still in the outbox or on Kafka move the
+// counters without Postgres showing it yet, so a repaired value is off by
+// whatever is in flight and is best applied while the event is quiet.
+func (s *reconcileService) Reconcile(ctx context.Context, repair bool) (*models.ReconcileReport, error) {
+	report := &models.ReconcileReport{StartedAt: time.Now(), Repair: repair}
+
+	booked, err := s.bookings.GetBookedSeats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.events.FindSeatCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ev := range events {
+		id := ev.ID.Hex()
+		sold := booked[id]
+
+		drift := models.SeatDrift{
+			EventID:       id,
+			Title:         ev.Title,
+			TotalSeats:    ev.TotalSeats,
+			BookedSeats:   sold.Booked,
+			PendingSeats:  sold.Pending,
+			ExpectedMongo: ev.TotalSeats - sold.Booked,
+			MongoSeats:    ev.AvailableSeats,
+			ExpectedRedis: ev.TotalSeats - sold.Booked - sold.Pending,
+		}
+
+		redisVal, err := s.redisSeats.Get(ctx, "seatsLeft:"+id).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		if err == nil {
+			seats, _ := strconv.ParseInt(redisVal, 10, 64)
+			drift.RedisSeats = &seats
+		}
+
+		redisDrifted := drift.RedisSeats == nil || *drift.RedisSeats != drift.ExpectedRedis
+		mongoDrifted := drift.MongoSeats != drift.ExpectedMongo
+		if !redisDrifted && !mongoDrifted {
+			report.EventsChecked++
+			continue
+		}
+
+		if repair {
+			s.repair(ctx, &drift, redisVal, redisDrifted, mongoDrifted)
+		}
+
+		report.EventsChecked++
+		report.Drifted = append(report.Drifted, drift)
+	}
+
+	report.FinishedAt = time.Now()
+
+	s.mu.Lock()
+	s.last = report
+	s.mu.Unlock()
+
+	log.Printf("Seat reconciliation checked %d events, %d drifted", report.EventsChecked, len(report.Drifted))
+	return report, nil
+}
+
+func (s *reconcileService) repair(ctx context.Context, drift *models.SeatDrift, observed string, redisDrifted, mongoDrifted bool) {
+	seatsKey := "seatsLeft:" + drift.EventID
+
+	if redisDrifted {
+		var ok bool
+		if drift.RedisSeats == nil {
+			ok, _ = s.redisSeats.SetNX(ctx, seatsKey, drift.ExpectedRedis, 0).Result()
+		} else {
+			n, _ := setIfUnchangedScript.Run(ctx, s.redisSeats, []string{seatsKey}, observed, drift.ExpectedRedis).Int()
+			ok = n == 1
+		}
+		drift.RedisRepaired = ok
+	}
+
+	if mongoDrifted {
+		err := s.events.UpdateFields(drift.EventID, map[string]interface{}{"available_seats": drift.ExpectedMongo})
+		if err != nil {
+			log.Printf("Failed to repair available_seats for event %s: %v", drift.EventID, err)
+		} else {
+			drift.MongoRepaired = true
+			s.redis.Del(ctx, "event:"+drift.EventID)
+		}
+	}
+}
+
+func (s *reconcileService) LastReport() *models.ReconcileReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// StartReconciler runs Reconcile every interval until ctx is cancelled.
+func StartReconciler(ctx context.Context, svc ReconcileService, interval time.Duration, repair bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.Reconcile(ctx, repair); err != nil {
+				log.Println("Seat reconciliation failed:", err)
+			}
+		}
+	}
+}
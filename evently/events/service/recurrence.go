@@ -0,0 +1,182 @@
+package service
+
+import (
+	"errors"
+	"events/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSeriesOccurrences caps how many events one series can expand into.
+const maxSeriesOccurrences = 200
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// validateRecurrence checks a rule and fills in its defaults: an interval
+// of 1 and, for weekly rules without weekdays, the weekday of Start.
+func validateRecurrence(rule *models.RecurrenceRule) error {
+	switch rule.Frequency {
+	case "daily", "weekly", "monthly":
+	default:
+		return errors.New("frequency must be daily, weekly or monthly")
+	}
+
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if rule.Interval < 0 {
+		return errors.New("interval must be greater than 0")
+	}
+
+	if rule.Start.IsZero() {
+		return errors.New("start is required")
+	}
+
+	if rule.Count < 0 || rule.Count > maxSeriesOccurrences {
+		return fmt.Errorf("count must be between 1 and %d", maxSeriesOccurrences)
+	}
+	if rule.Count == 0 && rule.Until == nil {
+		return errors.New("count or until is required")
+	}
+	if rule.Until != nil && rule.Until.Before(rule.Start) {
+		return errors.New("until must not be before start")
+	}
+
+	if len(rule.ByDay) > 0 && rule.Frequency != "weekly" {
+		return errors.New("by_day only applies to weekly series")
+	}
+	for i, day := range rule.ByDay {
+		code := strings.ToUpper(day)
+		if _, ok := weekdayCodes[code]; !ok {
+			return fmt.Errorf("unknown weekday %q, use MO, TU, WE, TH, FR, SA or SU", day)
+		}
+		rule.ByDay[i] = code
+	}
+
+	for _, date := range rule.Exceptions {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("exception %q must be a date (YYYY-MM-DD)", date)
+		}
+	}
+
+	return nil
+}
+
+// expandRecurrence lists the dates of a validated rule. As in RRULE, Count
+// counts the skipped exception dates too. Monthly occurrences fall on the
+// day of month of Start, and months without that day are left out. Dates
+// are worked out on the wall clock of loc, the venue's time zone, so an
+// occurrence keeps its local time across daylight saving changes.
+func expandRecurrence(rule models.RecurrenceRule, loc *time.Location) ([]time.Time, error) {
+	rule.Start = rule.Start.In(loc)
+
+	skip := make(map[string]bool, len(rule.Exceptions))
+	for _, date := range rule.Exceptions {
+		skip[date] = true
+	}
+
+	var dates []time.Time
+	generated := 0
+
+	// emit takes the next date of the rule and reports whether the rule
+	// goes on after it
+	emit := func(t time.Time) (bool, error) {
+		if rule.Until != nil && t.After(*rule.Until) {
+			return false, nil
+		}
+		if rule.Count > 0 && generated >= rule.Count {
+			return false, nil
+		}
+		if generated >= maxSeriesOccurrences {
+			return false, fmt.Errorf("a series can have at most %d occurrences", maxSeriesOccurrences)
+		}
+
+		generated++
+		if !skip[t.Format("2006-01-02")] {
+			dates = append(dates, t)
+		}
+		return true, nil
+	}
+
+	switch rule.Frequency {
+	case "daily":
+		for i := 0; ; i++ {
+			more, err := emit(rule.Start.AddDate(0, 0, i*rule.Interval))
+			if err != nil || !more {
+				return dates, err
+			}
+		}
+
+	case "monthly":
+		for i := 0; ; i++ {
+			t := rule.Start.AddDate(0, i*rule.Interval, 0)
+			if t.Day() != rule.Start.Day() {
+				// AddDate rolled a short month over; a month without the
+				// day still ends the rule once past until
+				if rule.Until != nil && t.After(*rule.Until) {
+					return dates, nil
+				}
+				continue
+			}
+
+			more, err := emit(t)
+			if err != nil || !more {
+				return dates, err
+			}
+		}
+
+	default:
+		days := weeklyOffsets(rule)
+		weekStart := rule.Start.AddDate(0, 0, -mondayOffset(rule.Start.Weekday()))
+
+		for w := 0; ; w++ {
+			base := weekStart.AddDate(0, 0, 7*w*rule.Interval)
+			for _, offset := range days {
+				t := base.AddDate(0, 0, offset)
+				if t.Before(rule.Start) {
+					continue
+				}
+
+				more, err := emit(t)
+				if err != nil || !more {
+					return dates, err
+				}
+			}
+		}
+	}
+}
+
+// weeklyOffsets is the days a weekly rule falls on, as days after Monday in
+// week order.
+func weeklyOffsets(rule models.RecurrenceRule) []int {
+	if len(rule.ByDay) == 0 {
+		return []int{mondayOffset(rule.Start.Weekday())}
+	}
+
+	seen := make(map[int]bool)
+	var offsets []int
+	for _, code := range rule.ByDay {
+		offset := mondayOffset(weekdayCodes[code])
+		if !seen[offset] {
+			seen[offset] = true
+			offsets = append(offsets, offset)
+		}
+	}
+
+	sort.Ints(offsets)
+	return offsets
+}
+
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
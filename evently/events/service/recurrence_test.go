@@ -0,0 +1,196 @@
+package service
+
+import (
+	"events/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+	d, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("bad test date %q: %v", value, err)
+	}
+	return d
+}
+
+func TestValidateRecurrence(t *testing.T) {
+	start := time.Date(2026, 1, 7, 10, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		rule    models.RecurrenceRule
+		wantErr string
+	}{
+		{"daily", models.RecurrenceRule{Frequency: "daily", Start: start, Count: 3}, ""},
+		{"until only", models.RecurrenceRule{Frequency: "monthly", Start: start, Until: &start}, ""},
+		{"unknown frequency", models.RecurrenceRule{Frequency: "yearly", Start: start, Count: 3}, "frequency"},
+		{"negative interval", models.RecurrenceRule{Frequency: "daily", Interval: -1, Start: start, Count: 3}, "interval"},
+		{"no start", models.RecurrenceRule{Frequency: "daily", Count: 3}, "start is required"},
+		{"count too high", models.RecurrenceRule{Frequency: "daily", Start: start, Count: maxSeriesOccurrences + 1}, "count"},
+		{"no end", models.RecurrenceRule{Frequency: "daily", Start: start}, "count or until"},
+		{"until before start", models.RecurrenceRule{Frequency: "daily", Start: start, Until: &before}, "until"},
+		{"by_day on daily", models.RecurrenceRule{Frequency: "daily", Start: start, Count: 3, ByDay: []string{"MO"}}, "by_day"},
+		{"unknown weekday", models.RecurrenceRule{Frequency: "weekly", Start: start, Count: 3, ByDay: []string{"XX"}}, "unknown weekday"},
+		{"bad exception", models.RecurrenceRule{Frequency: "daily", Start: start, Count: 3, Exceptions: []string{"07/01/2026"}}, "exception"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRecurrence(&tt.rule)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRecurrenceDefaults(t *testing.T) {
+	rule := models.RecurrenceRule{
+		Frequency: "weekly",
+		Start:     time.Date(2026, 1, 7, 10, 0, 0, 0, time.UTC),
+		Count:     3,
+		ByDay:     []string{"mo", "Fr"},
+	}
+	if err := validateRecurrence(&rule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Interval != 1 {
+		t.Fatalf("interval = %d, want 1", rule.Interval)
+	}
+	if !reflect.DeepEqual(rule.ByDay, []string{"MO", "FR"}) {
+		t.Fatalf("by_day = %v, want [MO FR]", rule.ByDay)
+	}
+}
+
+func TestExpandRecurrence(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		rule models.RecurrenceRule
+		loc  *time.Location
+		want []string
+	}{
+		{
+			name: "daily with interval",
+			rule: models.RecurrenceRule{Frequency: "daily", Interval: 2, Start: date(t, "2026-01-01T10:00:00Z"), Count: 3},
+			loc:  time.UTC,
+			want: []string{"2026-01-01T10:00:00Z", "2026-01-03T10:00:00Z", "2026-01-05T10:00:00Z"},
+		},
+		{
+			name: "count counts exceptions",
+			rule: models.RecurrenceRule{Frequency: "daily", Interval: 1, Start: date(t, "2026-01-01T10:00:00Z"), Count: 5, Exceptions: []string{"2026-01-02", "2026-01-04"}},
+			loc:  time.UTC,
+			want: []string{"2026-01-01T10:00:00Z", "2026-01-03T10:00:00Z", "2026-01-05T10:00:00Z"},
+		},
+		{
+			name: "until is inclusive",
+			rule: models.RecurrenceRule{Frequency: "daily", Interval: 2, Start: date(t, "2026-01-01T10:00:00Z"), Until: ptrTime(date(t, "2026-01-07T10:00:00Z"))},
+			loc:  time.UTC,
+			want: []string{"2026-01-01T10:00:00Z", "2026-01-03T10:00:00Z", "2026-01-05T10:00:00Z", "2026-01-07T10:00:00Z"},
+		},
+		{
+			name: "until cuts off before count",
+			rule: models.RecurrenceRule{Frequency: "daily", Interval: 2, Start: date(t, "2026-01-01T10:00:00Z"), Count: 10, Until: ptrTime(date(t, "2026-01-07T09:59:00Z"))},
+			loc:  time.UTC,
+			want: []string{"2026-01-01T10:00:00Z", "2026-01-03T10:00:00Z", "2026-01-05T10:00:00Z"},
+		},
+		{
+			name: "weekly by_day across week boundaries",
+			rule: models.RecurrenceRule{Frequency: "weekly", Interval: 1, Start: date(t, "2026-01-07T10:00:00Z"), Count: 5, ByDay: []string{"FR", "MO", "WE"}},
+			loc:  time.UTC,
+			want: []string{"2026-01-07T10:00:00Z", "2026-01-09T10:00:00Z", "2026-01-12T10:00:00Z", "2026-01-14T10:00:00Z", "2026-01-16T10:00:00Z"},
+		},
+		{
+			name: "fortnightly by_day starting late in the week",
+			rule: models.RecurrenceRule{Frequency: "weekly", Interval: 2, Start: date(t, "2026-01-09T10:00:00Z"), Count: 4, ByDay: []string{"MO", "FR"}},
+			loc:  time.UTC,
+			want: []string{"2026-01-09T10:00:00Z", "2026-01-19T10:00:00Z", "2026-01-23T10:00:00Z", "2026-02-02T10:00:00Z"},
+		},
+		{
+			name: "weekly without by_day keeps the start weekday",
+			rule: models.RecurrenceRule{Frequency: "weekly", Interval: 1, Start: date(t, "2026-01-07T10:00:00Z"), Count: 2},
+			loc:  time.UTC,
+			want: []string{"2026-01-07T10:00:00Z", "2026-01-14T10:00:00Z"},
+		},
+		{
+			name: "monthly on the 31st skips short months",
+			rule: models.RecurrenceRule{Frequency: "monthly", Interval: 1, Start: date(t, "2026-01-31T10:00:00Z"), Count: 4},
+			loc:  time.UTC,
+			want: []string{"2026-01-31T10:00:00Z", "2026-03-31T10:00:00Z", "2026-05-31T10:00:00Z", "2026-07-31T10:00:00Z"},
+		},
+		{
+			name: "monthly until falls in a skipped month",
+			rule: models.RecurrenceRule{Frequency: "monthly", Interval: 1, Start: date(t, "2026-01-31T10:00:00Z"), Until: ptrTime(date(t, "2026-04-15T00:00:00Z"))},
+			loc:  time.UTC,
+			want: []string{"2026-01-31T10:00:00Z", "2026-03-31T10:00:00Z"},
+		},
+		{
+			name: "weekly keeps local time across daylight saving",
+			rule: models.RecurrenceRule{Frequency: "weekly", Interval: 1, Start: date(t, "2026-03-22T18:00:00Z"), Count: 3},
+			loc:  berlin,
+			want: []string{"2026-03-22T19:00:00+01:00", "2026-03-29T19:00:00+02:00", "2026-04-05T19:00:00+02:00"},
+		},
+		{
+			name: "monthly keeps local time across daylight saving",
+			rule: models.RecurrenceRule{Frequency: "monthly", Interval: 1, Start: date(t, "2026-10-15T18:00:00Z"), Count: 2},
+			loc:  berlin,
+			want: []string{"2026-10-15T20:00:00+02:00", "2026-11-15T20:00:00+01:00"},
+		},
+		{
+			name: "weekdays and exceptions use the venue's dates",
+			rule: models.RecurrenceRule{Frequency: "weekly", Interval: 1, Start: date(t, "2026-01-06T04:00:00Z"), Count: 3, ByDay: []string{"MO"}, Exceptions: []string{"2026-01-12"}},
+			loc:  newYork,
+			want: []string{"2026-01-05T23:00:00-05:00", "2026-01-19T23:00:00-05:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates, err := expandRecurrence(tt.rule, tt.loc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make([]string, len(dates))
+			for i, d := range dates {
+				got[i] = d.Format(time.RFC3339)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("dates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandRecurrenceTooManyOccurrences(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	until := start.AddDate(2, 0, 0)
+
+	rule := models.RecurrenceRule{Frequency: "daily", Interval: 1, Start: start, Until: &until}
+	if _, err := expandRecurrence(rule, time.UTC); err == nil {
+		t.Fatal("expected an error for more than the maximum number of occurrences")
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
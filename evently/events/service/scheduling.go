@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultDurationMinutes is how long an event at a venue is taken to last
+// when it does not say.
+const defaultDurationMinutes = 180
+
+// venueLockTTL bounds how long a crashed request can keep a venue locked,
+// and venueLockWait how long another waits for it.
+const (
+	venueLockTTL  = 30 * time.Second
+	venueLockWait = 5 * time.Second
+)
+
+var (
+	ErrVenueConflict = errors.New("venue is already booked at that time")
+	ErrVenueBusy     = errors.New("venue is being scheduled by another request, try again")
+)
+
+// unlockVenueScript only releases a venue lock its holder still owns.
+var unlockVenueScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+    return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type venueLockKey struct{ venueID string }
+
+// withVenueLock runs fn holding venueLock:<venueId> in rdb, so that two
+// requests cannot both find a slot free and then both book it, and a venue
+// cannot shrink under an event being scheduled there. fn gets a context
+// that remembers the lock, and venue checks nested inside it for the same
+// venue run under it rather than wait on themselves.
+func withVenueLock(ctx context.Context, rdb *redis.Client, venueID string, fn func(ctx context.Context) error) error {
+	if venueID == "" || ctx.Value(venueLockKey{venueID}) != nil {
+		return fn(ctx)
+	}
+
+	key := "venueLock:" + venueID
+	token := primitive.NewObjectID().Hex()
+	deadline := time.Now().Add(venueLockWait)
+	for {
+		locked, err := rdb.SetNX(ctx, key, token, venueLockTTL).Result()
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return ErrVenueBusy
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	defer unlockVenueScript.Run(context.Background(), rdb, []string{key}, token)
+
+	return fn(context.WithValue(ctx, venueLockKey{venueID}, true))
+}
+
+// eventEnd is when an event lets its venue go.
+func eventEnd(event *models.Event) time.Time {
+	return event.Date.Add(time.Duration(event.Duration) * time.Minute)
+}
+
+// resolveVenue loads the venue an event is held at and fills in what the
+// event keeps of it: the venue's name, a default duration and, for an event
+// that brings no seats of its own, the venue's layout.
+func (s *eventService) resolveVenue(ctx context.Context, event *models.Event) (*models.Venue, error) {
+	if event.VenueID == "" {
+		return nil, errors.New("venue_id is required")
+	}
+
+	venue, err := s.venues.FindByID(ctx, event.VenueID)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Venue = venue.Name
+	if event.Duration == 0 {
+		event.Duration = defaultDurationMinutes
+	}
+
+	if venue.Layout != nil && event.SeatMap == nil && len(event.Tiers) == 0 && event.TotalSeats == 0 {
+		seats := int64(len(seatIDs(venue.Layout)))
+		event.SeatMap = venue.Layout
+		event.TotalSeats = seats
+		event.AvailableSeats = seats
+	}
+
+	return venue, nil
+}
+
+// checkVenue rejects an event that sells more seats than its venue holds or
+// that overlaps another event there. Occurrences of excludeSeries are left
+// out, as a series being rescheduled may take over its own slots. Callers
+// hold the venue's lock until the event is written.
+func (s *eventService) checkVenue(ctx context.Context, event *models.Event, venue *models.Venue, excludeSeries string) error {
+	if event.TotalSeats > venue.Capacity {
+		return fmt.Errorf("total_seats %d is more than %s holds (%d)", event.TotalSeats, venue.Name, venue.Capacity)
+	}
+
+	excludeID := ""
+	if !event.ID.IsZero() {
+		excludeID = event.ID.Hex()
+	}
+
+	conflicts, err := s.repo.FindVenueConflicts(ctx, venue.ID.Hex(), event.Date, eventEnd(event), excludeID, excludeSeries)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		other := conflicts[0]
+		return fmt.Errorf("%w: %s runs from %s to %s", ErrVenueConflict,
+			other.Title, other.Date.Format(time.RFC3339), eventEnd(&other).Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// checkUpdatedVenue runs the venue checks on an event as updates would
+// leave it, and copies the name of a new venue into the updates. Events
+// from before the venue registry have no venue to check against.
+func (s *eventService) checkUpdatedVenue(ctx context.Context, current *models.Event, updates map[string]interface{}) error {
+	next := *current
+	if id, ok := updates["venue_id"].(string); ok {
+		next.VenueID = id
+	}
+	if minutes, ok := updates["duration_minutes"].(int64); ok {
+		next.Duration = minutes
+	}
+	if seats, ok := updates["total_seats"].(int); ok {
+		next.TotalSeats = int64(seats)
+	}
+	if date, ok := updates["date"].(time.Time); ok {
+		next.Date = date
+	}
+
+	if next.VenueID == "" {
+		return nil
+	}
+
+	venue, err := s.venues.FindByID(ctx, next.VenueID)
+	if err != nil {
+		return err
+	}
+
+	if next.Duration == 0 {
+		next.Duration = defaultDurationMinutes
+		updates["duration_minutes"] = next.Duration
+	}
+	if next.Venue != venue.Name {
+		updates["venue"] = venue.Name
+	}
+
+	return s.checkVenue(ctx, &next, venue, "")
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+const maxSearchLimit = 100
+
+var searchSortOptions = []string{"relevance", "date", "-date", "price", "-price", "seats"}
+
+// SearchEvents finds upcoming events by text, venue, date, price and
+// availability. The seat counts of the page returned are the live ones
+// from Redis, like the upcoming list.
+func (s *eventService) SearchEvents(ctx context.Context, search models.EventSearch) (*models.EventSearchResult, error) {
+	if search.Sort == "" {
+		search.Sort = "date"
+		if search.Query != "" {
+			search.Sort = "relevance"
+		}
+	}
+
+	if !slices.Contains(searchSortOptions, search.Sort) {
+		return nil, fmt.Errorf("unknown sort %q", search.Sort)
+	}
+
+	if search.From != nil && search.To != nil && !search.To.After(*search.From) {
+		return nil, errors.New("to must be after from")
+	}
+
+	if search.MinPrice != nil && search.MaxPrice != nil && *search.MaxPrice < *search.MinPrice {
+		return nil, errors.New("max_price cannot be below min_price")
+	}
+
+	if search.Page < 1 {
+		search.Page = 1
+	}
+	if search.Limit < 1 {
+		search.Limit = 10
+	}
+	if search.Limit > maxSearchLimit {
+		search.Limit = maxSearchLimit
+	}
+
+	result, err := s.repo.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	s.applyLiveSeats(ctx, result.Events)
+	return result, nil
+}
+
+func (s *eventService) applyLiveSeats(ctx context.Context, events []models.EventSearchHit) {
+	if len(events) == 0 {
+		return
+	}
+
+	keys := make([]string, len(events))
+	for i, ev := range events {
+		keys[i] = "seatsLeft:" + ev.ID.Hex()
+	}
+
+	vals, err := s.redisSeats.MGet(ctx, keys...).Result()
+	if err != nil {
+		return
+	}
+
+	for i, val := range vals {
+		if str, ok := val.(string); ok {
+			seats, _ := strconv.ParseInt(str, 10, 64)
+			events[i].AvailableSeats = seats
+		}
+	}
+}
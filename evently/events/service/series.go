@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"fmt"
+	"log"
+	"time"
+)
+
+var ErrSeriesNotOwner = errors.New("series belongs to another organizer")
+
+// CreateSeries stores a series and creates its future occurrences, each an
+// event of its own with its own seat and price keys. Every occurrence is
+// checked before anything is stored, and if one still fails to be created
+// the series and the occurrences made so far are removed again.
+func (s *eventService) CreateSeries(ctx context.Context, series *models.EventSeries, organizerID string) (*models.SeriesDetail, error) {
+	if organizerID != "" {
+		series.OrganizerID = organizerID
+	}
+
+	var detail *models.SeriesDetail
+	err := withVenueLock(ctx, s.redis, series.Template.VenueID, func(ctx context.Context) error {
+		var err error
+		detail, err = s.createSeries(ctx, series)
+		return err
+	})
+	return detail, err
+}
+
+func (s *eventService) createSeries(ctx context.Context, series *models.EventSeries) (*models.SeriesDetail, error) {
+	dates, err := s.prepareSeries(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.series.Create(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.SeriesDetail{Series: *created, Occurrences: []models.Event{}}
+	for _, date := range dates {
+		event, err := s.CreateEvent(ctx, occurrenceEvent(created, date), "")
+		if err != nil {
+			s.removeSeries(ctx, created, detail.Occurrences)
+			return nil, fmt.Errorf("occurrence on %s: %w", date.Format(time.RFC3339), err)
+		}
+		detail.Occurrences = append(detail.Occurrences, *event)
+	}
+
+	return detail, nil
+}
+
+// removeSeries undoes a CreateSeries that failed part way. What cannot be
+// removed is logged, as the caller already has an error to report.
+func (s *eventService) removeSeries(ctx context.Context, series *models.EventSeries, occurrences []models.Event) {
+	for i := range occurrences {
+		if err := s.removeEvent(ctx, &occurrences[i]); err != nil {
+			log.Printf("Failed to remove occurrence %s of series %s: %v", occurrences[i].ID.Hex(), series.ID.Hex(), err)
+		}
+	}
+
+	if err := s.series.Delete(ctx, series.ID); err != nil {
+		log.Printf("Failed to remove series %s: %v", series.ID.Hex(), err)
+	}
+	s.invalidateUpcoming(ctx)
+}
+
+func (s *eventService) GetSeries(ctx context.Context, id string) (*models.SeriesDetail, error) {
+	series, err := s.series.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := s.repo.FindBySeries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if occurrences == nil {
+		occurrences = []models.Event{}
+	}
+
+	return &models.SeriesDetail{Series: *series, Occurrences: occurrences}, nil
+}
+
+// UpdateSeries replaces a series' template and recurrence and brings its
+// future occurrences in line. Occurrences edited on their own keep their
+// edits. Dates the new rule drops are deleted while still drafts and kept
+// otherwise, as their bookings have to be cancelled by hand; dates it adds
+// get new occurrences. Seat layouts only apply to new occurrences.
+func (s *eventService) UpdateSeries(ctx context.Context, id string, changes *models.EventSeries, organizerID string) (*models.SeriesUpdateReport, error) {
+	series, err := s.series.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if organizerID != "" && series.OrganizerID != organizerID {
+		return nil, ErrSeriesNotOwner
+	}
+
+	series.Template = changes.Template
+	series.Recurrence = changes.Recurrence
+
+	var report *models.SeriesUpdateReport
+	err = withVenueLock(ctx, s.redis, series.Template.VenueID, func(ctx context.Context) error {
+		var err error
+		report, err = s.rescheduleSeries(ctx, series)
+		return err
+	})
+	return report, err
+}
+
+// rescheduleSeries stores a series' new template and recurrence and brings
+// its future occurrences in line with them.
+func (s *eventService) rescheduleSeries(ctx context.Context, series *models.EventSeries) (*models.SeriesUpdateReport, error) {
+	id := series.ID.Hex()
+
+	dates, err := s.prepareSeries(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.series.Replace(ctx, series); err != nil {
+		return nil, err
+	}
+
+	occurrences, err := s.repo.FindBySeries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make(map[int64]bool, len(dates))
+	for _, date := range dates {
+		scheduled[date.Unix()] = true
+	}
+
+	report := &models.SeriesUpdateReport{Series: *series}
+	existing := make(map[int64]bool, len(occurrences))
+	now := time.Now()
+
+	for i := range occurrences {
+		occurrence := &occurrences[i]
+		if !occurrence.Date.After(now) {
+			continue
+		}
+		existing[occurrence.Date.Unix()] = true
+
+		if !scheduled[occurrence.Date.Unix()] {
+			if eventStatus(occurrence) == "draft" {
+				if err := s.DeleteEvent(ctx, occurrence.ID.Hex(), ""); err != nil {
+					return nil, err
+				}
+				report.Removed++
+			} else {
+				report.Kept = append(report.Kept, occurrence.ID.Hex())
+			}
+			continue
+		}
+
+		if occurrence.SeriesOverride {
+			report.Skipped++
+			continue
+		}
+
+		updates := templateUpdates(series.Template)
+		if err := validateUpdates(updates); err != nil {
+			return nil, err
+		}
+		if _, err := s.applyUpdates(ctx, occurrence, updates); err != nil {
+			return nil, err
+		}
+		report.Updated++
+	}
+
+	for _, date := range dates {
+		if existing[date.Unix()] {
+			continue
+		}
+		if _, err := s.CreateEvent(ctx, occurrenceEvent(series, date), ""); err != nil {
+			return nil, err
+		}
+		report.Created++
+	}
+
+	log.Printf("Series %s updated: %d occurrences updated, %d overridden, %d created, %d removed, %d kept",
+		id, report.Updated, report.Skipped, report.Created, report.Removed, len(report.Kept))
+	return report, nil
+}
+
+// prepareSeries validates a series and returns the future dates of its
+// occurrences. Every occurrence is checked as the event it will become,
+// and must fit in its venue next to the events already booked there and to
+// each other.
+func (s *eventService) prepareSeries(ctx context.Context, series *models.EventSeries) ([]time.Time, error) {
+	series.Template.Category = normalizeLabel(series.Template.Category)
+	series.Template.Tags = normalizeTags(series.Template.Tags)
+
+	if err := validateRecurrence(&series.Recurrence); err != nil {
+		return nil, err
+	}
+
+	sample := occurrenceEvent(series, series.Recurrence.Start)
+	applyTierDefaults(sample)
+
+	venue, err := s.resolveVenue(ctx, sample)
+	if err != nil {
+		return nil, err
+	}
+	series.Template.Venue = sample.Venue
+	series.Template.Duration = sample.Duration
+
+	dates, err := expandRecurrence(series.Recurrence, venueLocation(venue))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	future := dates[:0]
+	for _, date := range dates {
+		if date.After(now) {
+			future = append(future, date)
+		}
+	}
+	if len(future) == 0 {
+		return nil, errors.New("the recurrence has no future occurrences")
+	}
+	sample.Date = future[0]
+
+	if sample.Status == "" {
+		sample.Status = "draft"
+	}
+	if err := validate(sample); err != nil {
+		return nil, err
+	}
+
+	ownSlots := ""
+	if !series.ID.IsZero() {
+		ownSlots = series.ID.Hex()
+	}
+
+	for i, date := range future {
+		if i > 0 && eventEnd(sample).After(date) {
+			return nil, errors.New("occurrences of the series overlap, shorten duration_minutes or space them out")
+		}
+
+		sample.Date = date
+		if err := validate(sample); err != nil {
+			return nil, fmt.Errorf("occurrence on %s: %w", date.Format(time.RFC3339), err)
+		}
+		if err := s.checkVenue(ctx, sample, venue, ownSlots); err != nil {
+			return nil, fmt.Errorf("occurrence on %s: %w", date.Format(time.RFC3339), err)
+		}
+	}
+
+	return future, nil
+}
+
+// occurrenceEvent is the event a series has on date, fully available.
+func occurrenceEvent(series *models.EventSeries, date time.Time) *models.Event {
+	t := series.Template
+
+	var tiers []models.TicketTier
+	if len(t.Tiers) > 0 {
+		tiers = append([]models.TicketTier(nil), t.Tiers...)
+	}
+
+	return &models.Event{
+		Title:          t.Title,
+		Description:    t.Description,
+		Venue:          t.Venue,
+		VenueID:        t.VenueID,
+		Category:       t.Category,
+		Tags:           append([]string(nil), t.Tags...),
+		OrganizerID:    series.OrganizerID,
+		SeriesID:       series.ID.Hex(),
+		Date:           date,
+		Duration:       t.Duration,
+		Price:          t.Price,
+		AvailableSeats: t.TotalSeats,
+		TotalSeats:     t.TotalSeats,
+		Status:         t.Status,
+		SeatMap:        t.SeatMap,
+		Tiers:          tiers,
+		RefundPolicy:   t.RefundPolicy,
+		PricingRules:   t.PricingRules,
+	}
+}
+
+// templateUpdates are the template fields a series edit copies onto its
+// occurrences, in the form UpdateEvent takes them. A tiered event's price
+// comes from its tiers, which do not change after creation.
+func templateUpdates(t models.SeriesTemplate) map[string]interface{} {
+	tags := make([]interface{}, len(t.Tags))
+	for i, tag := range t.Tags {
+		tags[i] = tag
+	}
+
+	updates := map[string]interface{}{
+		"title":            t.Title,
+		"description":      t.Description,
+		"venue_id":         t.VenueID,
+		"duration_minutes": float64(t.Duration),
+		"category":         t.Category,
+		"tags":             tags,
+	}
+
+	if len(t.Tiers) == 0 {
+		updates["price"] = t.Price
+	}
+	if t.RefundPolicy != nil {
+		updates["refund_policy"] = t.RefundPolicy
+	}
+	if t.PricingRules != nil {
+		updates["pricing_rules"] = t.PricingRules
+	}
+
+	return updates
+}
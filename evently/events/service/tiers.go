@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"events/models"
+	"fmt"
+	"strings"
+)
+
+// tierSeatsKey and tierPriceKey hold a tier's seat counter and price next to
+// the event-wide seatsLeft:<id> and price:<id> keys.
+func tierSeatsKey(eventID, tier string) string {
+	return "seatsLeft:" + eventID + ":" + tier
+}
+
+func tierPriceKey(eventID, tier string) string {
+	return "price:" + eventID + ":" + tier
+}
+
+// tieredKey marks an event with ticket tiers for the bookings consumer,
+// which then refuses requests that do not name a tier.
+func tieredKey(eventID string) string {
+	return "tiered:" + eventID
+}
+
+// applyTierDefaults fills in what a tiered event does not ask for: every
+// tier starts fully available and the event's own price is its cheapest
+// ticket, which is what listings show.
+func applyTierDefaults(e *models.Event) {
+	if len(e.Tiers) == 0 {
+		return
+	}
+
+	e.Price = e.Tiers[0].Price
+	for i := range e.Tiers {
+		e.Tiers[i].AvailableSeats = e.Tiers[i].TotalSeats
+		if e.Tiers[i].Price < e.Price {
+			e.Price = e.Tiers[i].Price
+		}
+	}
+}
+
+func validateTiers(e *models.Event) error {
+	if e.SeatMap != nil {
+		return errors.New("an event cannot have both a seat map and ticket tiers")
+	}
+
+	seen := make(map[string]bool)
+	var total int64
+	for _, tier := range e.Tiers {
+		if strings.TrimSpace(tier.Name) == "" {
+			return errors.New("tier name is required")
+		}
+
+		if strings.Contains(tier.Name, ":") {
+			return fmt.Errorf("tier name %s must not contain ':'", tier.Name)
+		}
+
+		if seen[tier.Name] {
+			return fmt.Errorf("duplicate tier %s", tier.Name)
+		}
+		seen[tier.Name] = true
+
+		if tier.Price <= 0 {
+			return fmt.Errorf("price of tier %s must be greater than 0", tier.Name)
+		}
+
+		if tier.TotalSeats <= 0 {
+			return fmt.Errorf("total_seats of tier %s must be greater than 0", tier.Name)
+		}
+
+		total += tier.TotalSeats
+	}
+
+	if total != e.TotalSeats || e.AvailableSeats != e.TotalSeats {
+		return errors.New("tier seats must add up to total_seats, all available")
+	}
+
+	return nil
+}
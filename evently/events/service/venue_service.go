@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"events/models"
+	"events/repository"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type VenueService interface {
+	CreateVenue(ctx context.Context, venue *models.Venue) (*models.Venue, error)
+	GetVenue(ctx context.Context, id string) (*models.Venue, error)
+	GetAllVenues(ctx context.Context) ([]models.Venue, error)
+	UpdateVenue(ctx context.Context, id string, venue *models.Venue) (*models.Venue, error)
+}
+
+type venueService struct {
+	repo   repository.VenueRepository
+	events repository.EventRepository
+	redis  *redis.Client
+}
+
+func NewVenueService(r repository.VenueRepository, events repository.EventRepository, redisClient *redis.Client) VenueService {
+	return &venueService{
+		repo:   r,
+		events: events,
+		redis:  redisClient,
+	}
+}
+
+func (s *venueService) CreateVenue(ctx context.Context, venue *models.Venue) (*models.Venue, error) {
+	if err := validateVenue(venue); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, venue)
+}
+
+func (s *venueService) GetVenue(ctx context.Context, id string) (*models.Venue, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *venueService) GetAllVenues(ctx context.Context) ([]models.Venue, error) {
+	venues, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if venues == nil {
+		venues = []models.Venue{}
+	}
+
+	return venues, nil
+}
+
+// UpdateVenue replaces a venue's details. Its capacity cannot drop below the
+// seats an upcoming event there already sells, and a new name is copied
+// onto its events. A new layout only applies to events created after it.
+// The capacity check and the write hold the venue's scheduling lock, so no
+// event is scheduled against the old capacity in between.
+func (s *venueService) UpdateVenue(ctx context.Context, id string, venue *models.Venue) (*models.Venue, error) {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateVenue(venue); err != nil {
+		return nil, err
+	}
+
+	var upcoming []models.Event
+	err = withVenueLock(ctx, s.redis, id, func(ctx context.Context) error {
+		var err error
+		upcoming, err = s.events.FindUpcomingAtVenue(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range upcoming {
+			if event.TotalSeats > venue.Capacity {
+				return fmt.Errorf("%s on %s sells %d seats, more than a capacity of %d",
+					event.Title, event.Date.Format(time.RFC3339), event.TotalSeats, venue.Capacity)
+			}
+		}
+
+		venue.ID = current.ID
+		venue.CreatedAt = current.CreatedAt
+		return s.repo.Replace(ctx, venue)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if venue.Name != current.Name {
+		if err := s.events.RenameVenue(ctx, id, venue.Name); err != nil {
+			return nil, err
+		}
+
+		keys, _ := s.redis.Keys(ctx, "events:upcoming:*").Result()
+		for _, event := range upcoming {
+			keys = append(keys, "event:"+event.ID.Hex())
+		}
+		if len(keys) > 0 {
+			s.redis.Del(ctx, keys...)
+		}
+	}
+
+	return venue, nil
+}
+
+func validateVenue(v *models.Venue) error {
+	v.Name = strings.TrimSpace(v.Name)
+	v.Address = strings.TrimSpace(v.Address)
+
+	if v.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if v.Address == "" {
+		return errors.New("address is required")
+	}
+
+	if v.Capacity <= 0 {
+		return errors.New("capacity must be greater than 0")
+	}
+
+	if v.Timezone == "" {
+		return errors.New("timezone is required")
+	}
+
+	if _, err := time.LoadLocation(v.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", v.Timezone)
+	}
+
+	if v.Layout != nil {
+		if err := validateSeatMap(v.Layout); err != nil {
+			return err
+		}
+
+		if int64(len(seatIDs(v.Layout))) > v.Capacity {
+			return errors.New("layout has more seats than the venue's capacity")
+		}
+	}
+
+	return nil
+}
+
+// venueLocation is the time zone the venue's events are scheduled in. Venues
+// stored before time zones were required fall back to UTC.
+func venueLocation(venue *models.Venue) *time.Location {
+	loc, err := time.LoadLocation(venue.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
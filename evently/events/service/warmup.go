@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"events/models"
+	"log"
+)
+
+// WarmCounters rebuilds the seatsLeft:, seatMap:, tiered:, price:, refundPolicy: and
+// sales: keys of every upcoming event and its tiers from Mongo, e.g. after the seats or price Redis was
+// flushed. Keys that already exist are left alone since live counters are
+// ahead of Mongo while bookings are in flight. When seatsLeft: was lost the
+// seatsFree: set of a seat-mapped event went with it, and it is rebuilt from
+// the seat map minus the seats bookings in Postgres occupy.
+func (s *eventService) WarmCounters(ctx context.Context) (*models.WarmupReport, error) {
+	events, err := s.repo.FindUpcomingCounters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.WarmupReport{}
+	for i := range events {
+		ev := &events[i]
+		id := ev.ID.Hex()
+
+		set, err := s.redisSeats.SetNX(ctx, "seatsLeft:"+id, ev.AvailableSeats, 0).Result()
+		if err != nil {
+			return nil, err
+		}
+		if set {
+			report.SeatsRestored++
+		}
+
+		if ev.SeatMap != nil {
+			if err := s.redisSeats.SetNX(ctx, seatMapKey(id), 1, 0).Err(); err != nil {
+				return nil, err
+			}
+
+			// a sold out event has no seatsFree: set either, so only a lost
+			// seatsLeft: says the set needs rebuilding
+			if set {
+				if err := s.restoreFreeSeats(ctx, id, ev.SeatMap); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		set, err = s.redisPrice.SetNX(ctx, "price:"+id, ev.Price, 0).Result()
+		if err != nil {
+			return nil, err
+		}
+		if set {
+			report.PricesRestored++
+		}
+
+		if len(ev.Tiers) > 0 {
+			if err := s.redisSeats.SetNX(ctx, tieredKey(id), 1, 0).Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, tier := range ev.Tiers {
+			set, err = s.redisSeats.SetNX(ctx, tierSeatsKey(id, tier.Name), tier.AvailableSeats, 0).Result()
+			if err != nil {
+				return nil, err
+			}
+			if set {
+				report.SeatsRestored++
+			}
+
+			set, err = s.redisPrice.SetNX(ctx, tierPriceKey(id, tier.Name), tier.Price, 0).Result()
+			if err != nil {
+				return nil, err
+			}
+			if set {
+				report.PricesRestored++
+			}
+		}
+
+		if ev.RefundPolicy != nil {
+			data, err := refundPolicyData(ev)
+			if err != nil {
+				return nil, err
+			}
+
+			set, err = s.redisPrice.SetNX(ctx, "refundPolicy:"+id, data, 0).Result()
+			if err != nil {
+				return nil, err
+			}
+			if set {
+				report.PoliciesRestored++
+			}
+		}
+
+		data, err := salesWindowData(ev)
+		if err != nil {
+			return nil, err
+		}
+
+		set, err = s.redisPrice.SetNX(ctx, "sales:"+id, data, 0).Result()
+		if err != nil {
+			return nil, err
+		}
+		if set {
+			report.SalesWindowsRestored++
+		}
+
+		report.EventsChecked++
+	}
+
+	log.Printf("Counter warm-up checked %d events, restored %d seat counters and %d prices",
+		report.EventsChecked, report.SeatsRestored, report.PricesRestored)
+	return report, nil
+}
+
+// restoreFreeSeats rebuilds seatsFree:<id> from the event's seat map minus
+// the seats confirmed, held and payment_pending bookings occupy.
+func (s *eventService) restoreFreeSeats(ctx context.Context, id string, seatMap *models.SeatMap) error {
+	taken, err := s.bookings.GetTakenSeatIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	takenSet := make(map[string]bool, len(taken))
+	for _, seatID := range taken {
+		takenSet[seatID] = true
+	}
+
+	var members []interface{}
+	for _, seatID := range seatIDs(seatMap) {
+		if !takenSet[seatID] {
+			members = append(members, seatID)
+		}
+	}
+
+	if len(members) == 0 {
+		return nil
+	}
+	return s.redisSeats.SAdd(ctx, "seatsFree:"+id, members...).Err()
+}
@@ -36,12 +36,11 @@ func NewProducer(broker string) *Producer {
 }
 
 func (p *Producer) Publish(topic string, key, value []byte) error {
-	p.writer.Topic = topic
-
 	log.Printf("Publishing message to Kafka: topic=%s, key=%s, value=%s\n", topic, string(key), string(value))
 
 	err := p.writer.WriteMessages(context.Background(),
 		kafka.Message{
+			Topic: topic,
 			Key:   key,
 			Value: value,
 		},
@@ -33,7 +33,13 @@ func main() {
 
 	)
 
-	routes.RegisterRoutes(r, producer, redis)
+	redisReq := newRedisClient(
+		mustGetEnv("REDIS_REQUESTS_HOST"),
+		mustGetEnv("REDIS_REQUESTS_PORT"),
+		mustGetEnv("REDIS_REQUESTS_PASSWORD"),
+	)
+
+	routes.RegisterRoutes(r, producer, redis, redisReq)
 	port := mustGetEnv("PORT")
 	log.Println("Gateway service running on port " + port)
 	if err := r.Run("0.0.0.0:" + port); err != nil {
@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const idempotencyTTL = 24 * time.Hour
+
+// scopedRequestID turns a client's idempotency key into the request id the
+// booking runs under. Hashing in the user keeps one user's key from ever
+// landing on another user's booking.
+func scopedRequestID(userID, key string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("evently:"+userID+":"+key)).String()
+}
+
+// queueIdempotent queues a new booking, hold or order. With an
+// Idempotency-Key header (or, for older clients, a request_id in the body)
+// a repeat of the same request within idempotencyTTL is answered with the
+// original request's result instead of being queued again. The bookings
+// consumer also refuses to book a request id twice, so this is the fast
+// path rather than the guarantee.
+//
+// A header key is scoped to the user and hashed into the request id. A body
+// request_id stays the request id, so older clients can still look their
+// booking up by it; one already taken by another user is refused.
+func queueIdempotent(c *gin.Context, topic string, body map[string]interface{}) {
+	userID := c.GetHeader("X-User-Id")
+
+	key := c.GetHeader("Idempotency-Key")
+	requestID := ""
+	if key != "" {
+		requestID = scopedRequestID(userID, key)
+	} else {
+		key, _ = body["request_id"].(string)
+		requestID = key
+	}
+
+	if key == "" || userID == "" {
+		delete(body, "request_id")
+		queueRequest(c, topic, body)
+		return
+	}
+
+	body["request_id"] = requestID
+
+	ctx := c.Request.Context()
+	idemKey := "idempotency:" + requestID
+
+	fresh, err := requestsRedis.SetNX(ctx, idemKey, userID, idempotencyTTL).Result()
+	if err != nil {
+		log.Println("Failed to record idempotency key:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process request"})
+		return
+	}
+
+	if !fresh {
+		owner, err := requestsRedis.Get(ctx, idemKey).Result()
+		if err != nil && err != redis.Nil {
+			log.Println("Failed to read idempotency key:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process request"})
+			return
+		}
+		if err == nil && owner != userID {
+			c.JSON(http.StatusConflict, gin.H{"error": "request_id is already in use"})
+			return
+		}
+
+		log.Printf("Replaying request %s for idempotency key %s", requestID, key)
+		replayRequest(c, requestID, body)
+		return
+	}
+
+	queueRequest(c, topic, body)
+
+	// nothing was queued, so a retry must be allowed through
+	if c.Writer.Status() >= http.StatusInternalServerError {
+		requestsRedis.Del(ctx, idemKey)
+	}
+}
+
+// replayRequest answers a repeated request with where the original stands.
+// The request's state only lives for a few minutes, after which the booking
+// it left behind tells.
+func replayRequest(c *gin.Context, requestID string, body map[string]interface{}) {
+	c.Header("Idempotent-Replayed", "true")
+	_, isOrder := body["items"]
+
+	state, err := requestsRedis.Get(c.Request.Context(), "bookingRequest:"+requestID).Result()
+	if err == nil && isOutcome(state) {
+		respondWithOutcome(c, requestID, state, isOrder)
+		return
+	}
+
+	if err == redis.Nil && replayFromBookingView(c, requestID, isOrder) {
+		return
+	}
+
+	status := "request already queued"
+	if err == nil {
+		status = fmt.Sprintf("request already queued, currently %s", state)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": status, "request_id": requestID})
+}
+
+// replayFromBookingView answers a replay from the booking (or order) row of
+// a request whose state has expired. It reports false when there is no row
+// yet or it is still waiting on payment.
+func replayFromBookingView(c *gin.Context, requestID string, isOrder bool) bool {
+	path := "/api/v1/bookings/request/" + requestID
+	if isOrder {
+		path = "/api/v1/bookings/orders/" + requestID
+	}
+
+	booking, err := fetchBookingView(c.Request.Context(), path)
+	if err != nil {
+		return false
+	}
+
+	var row struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(booking, &row); err != nil {
+		return false
+	}
+
+	state := row.Status
+	if state == "confirmed" {
+		state = "success"
+	}
+	if !isOutcome(state) {
+		return false
+	}
+
+	if state != "success" && state != "held" {
+		c.JSON(http.StatusConflict, gin.H{"status": state, "request_id": requestID})
+		return true
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": state, "request_id": requestID, "booking": booking})
+	return true
+}
@@ -1,10 +1,15 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"gateway/kafka"
 	"gateway/middleware"
@@ -22,6 +27,11 @@ func InitProducer(broker string) {
 	log.Printf("Kafka producer initialized for broker %s\n", broker)
 }
 
+const (
+	defaultHoldMinutes = 10
+	maxHoldMinutes     = 30
+)
+
 func HandleBookingRequest(c *gin.Context) {
 	log.Println("HandleBookingRequest called")
 
@@ -33,6 +43,149 @@ func HandleBookingRequest(c *gin.Context) {
 	}
 	log.Println("Request body:", body)
 
+	// holds and confirmations have their own endpoints
+	delete(body, "hold_minutes")
+	delete(body, "action")
+
+	if c.Request.Method == http.MethodPost {
+		queueIdempotent(c, selectTopic(http.MethodPost), body)
+		return
+	}
+
+	// a cancel with seats or seat_ids only gives back part of the booking
+	if seats, ok := body["seats"]; ok && c.Request.Method == http.MethodDelete {
+		n, isNumber := seats.(float64)
+		if !isNumber || n <= 0 || n != math.Trunc(n) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "seats must be a positive whole number"})
+			return
+		}
+	}
+
+	queueRequest(c, selectTopic(c.Request.Method), body)
+}
+
+// HandleHoldRequest serves POST /bookings/holds, which reserves seats for a
+// limited time, and POST /bookings/holds/:request_id/confirm, which turns
+// that hold into a booking.
+func HandleHoldRequest(c *gin.Context, path string) {
+	log.Println("HandleHoldRequest called, path:", path)
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if len(parts) == 3 && parts[2] == "confirm" {
+		body := map[string]interface{}{
+			"request_id": parts[1],
+			"action":     "confirm",
+		}
+		queueRequest(c, selectTopic(http.MethodPost), body)
+		return
+	}
+
+	if len(parts) != 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown holds endpoint"})
+		return
+	}
+
+	var body map[string]interface{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		log.Println("Invalid JSON:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	minutes := float64(defaultHoldMinutes)
+	if v, ok := body["hold_minutes"]; ok {
+		m, ok := v.(float64)
+		if !ok || m < 1 || m > maxHoldMinutes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("hold_minutes must be between 1 and %d", maxHoldMinutes)})
+			return
+		}
+		minutes = m
+	}
+
+	body["hold_minutes"] = int64(minutes)
+	delete(body, "action")
+
+	queueIdempotent(c, selectTopic(http.MethodPost), body)
+}
+
+const maxOrderItems = 10
+
+// HandleOrderRequest serves POST /bookings/orders, which books seats for
+// several events under one request id. Either every item is booked or none.
+func HandleOrderRequest(c *gin.Context) {
+	log.Println("HandleOrderRequest called")
+
+	var body struct {
+		Items []struct {
+			EventID string `json:"event_id"`
+			Tier    string `json:"tier,omitempty"`
+			Seats   int64  `json:"seats"`
+		} `json:"items"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		log.Println("Invalid JSON:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if len(body.Items) == 0 || len(body.Items) > maxOrderItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("an order must have between 1 and %d items", maxOrderItems)})
+		return
+	}
+
+	seen := make(map[string]bool, len(body.Items))
+	for _, item := range body.Items {
+		if item.EventID == "" || item.Seats <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "every item needs an event_id and seats greater than 0"})
+			return
+		}
+
+		if seen[item.EventID+":"+item.Tier] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "each event and tier can only appear once per order"})
+			return
+		}
+		seen[item.EventID+":"+item.Tier] = true
+	}
+
+	queueIdempotent(c, selectTopic(http.MethodPost), map[string]interface{}{"items": body.Items})
+}
+
+// exchangeTopic carries requests to move a booking to another event, which
+// the bookings consumer runs as one saga.
+const exchangeTopic = "exchange"
+
+// HandleExchangeRequest serves POST /bookings/exchanges, which moves all the
+// seats of a confirmed booking to another event, such as a later showing.
+func HandleExchangeRequest(c *gin.Context) {
+	log.Println("HandleExchangeRequest called")
+
+	var body struct {
+		BookingID string   `json:"booking_id"`
+		EventID   string   `json:"event_id"`
+		Tier      string   `json:"tier,omitempty"`
+		SeatIDs   []string `json:"seat_ids,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		log.Println("Invalid JSON:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if body.BookingID == "" || body.EventID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "booking_id and event_id are required"})
+		return
+	}
+
+	queueIdempotent(c, exchangeTopic, map[string]interface{}{
+		"booking_id": body.BookingID,
+		"event_id":   body.EventID,
+		"tier":       body.Tier,
+		"seat_ids":   body.SeatIDs,
+	})
+}
+
+func queueRequest(c *gin.Context, topic string, body map[string]interface{}) {
 	if _, ok := body["request_id"]; !ok {
 
 		body["request_id"] = uuid.New().String()
@@ -57,28 +210,73 @@ func HandleBookingRequest(c *gin.Context) {
 		return
 	}
 
-	topic := selectTopic(c.Request.Method)
-	log.Printf("Publishing to topic: %s, key: %s\n", topic, body["request_id"])
+	requestID := body["request_id"].(string)
+
+	// cancels and hold confirmations name a request that already has an owner
+	_, isConfirm := body["action"]
+	if topic != selectTopic(http.MethodDelete) && !isConfirm {
+		if err := recordOwner(c.Request.Context(), requestID, userID); err != nil {
+			log.Println("Failed to record request owner:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process request"})
+			return
+		}
+	}
+
+	// with Prefer: wait=N the client gets the outcome instead of a 202, as
+	// long as the request settles in time. Confirming a hold starts from
+	// the held state, so it is not waited on.
+	wait := time.Duration(0)
+	if (topic == selectTopic(http.MethodPost) || topic == exchangeTopic) && !isConfirm {
+		wait = preferredWait(c)
+	}
+
+	var sub *redis.PubSub
+	if wait > 0 {
+		sub, err = subscribeOutcome(c.Request.Context(), requestID)
+		if err != nil {
+			log.Println("Failed to subscribe to booking status, not waiting:", err)
+			wait = 0
+		} else {
+			defer sub.Close()
+		}
+	}
 
-	if err := producer.Publish(topic, []byte(body["request_id"].(string)), newBody); err != nil {
+	log.Printf("Publishing to topic: %s, key: %s\n", topic, requestID)
+
+	if err := producer.Publish(topic, []byte(requestID), newBody); err != nil {
 		log.Println("Failed to publish to Kafka:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to publish"})
 		return
 	}
 
 	log.Println("request successfully queued")
-	c.JSON(http.StatusAccepted, gin.H{"status": "request queued", "request_id": body["request_id"]})
+
+	if wait > 0 {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), wait)
+		defer cancel()
+
+		if state := waitForOutcome(ctx, sub, requestID); state != "" {
+			c.Header("Preference-Applied", fmt.Sprintf("wait=%d", int(wait.Seconds())))
+			_, isOrder := body["items"]
+			respondWithOutcome(c, requestID, state, isOrder)
+			return
+		}
+		log.Printf("Request %s did not settle within %s, answering asynchronously", requestID, wait)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "request queued", "request_id": requestID})
 }
 
-func RegisterRoutes(r *gin.Engine, prod *kafka.Producer, redis *redis.Client) {
+func RegisterRoutes(r *gin.Engine, prod *kafka.Producer, redis *redis.Client, redisReq *redis.Client) {
 	producer = prod
+	requestsRedis = redisReq
 	log.Println("Registering routes")
 
 	api := r.Group("/api")
 
 	usersBaseURL := mustGetEnv("USERS_SERVICE_URL")
 	eventsBaseURL := mustGetEnv("EVENTS_SERVICE_URL")
-	bookingsViewBaseURL := mustGetEnv("BOOKINGS_VIEW_SERVICE_URL")
+	bookingsViewBaseURL = mustGetEnv("BOOKINGS_VIEW_SERVICE_URL")
 
 	api.Any("/users/*path", proxy.ReverseProxy(usersBaseURL))
 
@@ -93,7 +291,17 @@ func RegisterRoutes(r *gin.Engine, prod *kafka.Producer, redis *redis.Client) {
 			method := c.Request.Method
 			log.Println("Received /bookings request, method:", method)
 
-			if method == http.MethodGet {
+			path := c.Param("path")
+
+			if method == http.MethodGet && strings.HasPrefix(path, "/stream/") {
+				HandleBookingStream(c, redisReq, strings.TrimPrefix(path, "/stream/"))
+			} else if method == http.MethodPost && strings.HasPrefix(path, "/holds") {
+				HandleHoldRequest(c, path)
+			} else if method == http.MethodPost && path == "/orders" {
+				HandleOrderRequest(c)
+			} else if method == http.MethodPost && path == "/exchanges" {
+				HandleExchangeRequest(c)
+			} else if method == http.MethodGet {
 				proxy.ReverseProxy(bookingsViewBaseURL)(c)
 			} else if method == http.MethodPost || method == http.MethodDelete {
 				HandleBookingRequest(c)
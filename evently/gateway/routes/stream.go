@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	streamMaxDuration  = 10 * time.Minute
+	streamPingInterval = 15 * time.Second
+)
+
+var terminalStates = map[string]bool{
+	"success":        true,
+	"failed":         true,
+	"cancelled":      true,
+	"payment_failed": true,
+	"expired":        true,
+}
+
+// recordOwner remembers which user queued a request, for as long as the
+// request can be replayed, so only they can follow its status.
+func recordOwner(ctx context.Context, requestID, userID string) error {
+	return requestsRedis.SetNX(ctx, "requestOwner:"+requestID, userID, idempotencyTTL).Err()
+}
+
+// requestOwner is the user who queued a request. Once the gateway has
+// forgotten, the booking the request left behind tells; "" means neither
+// knows the request.
+func requestOwner(ctx context.Context, requestID string) (string, error) {
+	owner, err := requestsRedis.Get(ctx, "requestOwner:"+requestID).Result()
+	if err == nil {
+		return owner, nil
+	}
+	if err != redis.Nil {
+		return "", err
+	}
+
+	data, err := fetchBookingView(ctx, "/api/v1/bookings/request/"+requestID)
+	if err != nil {
+		return "", nil
+	}
+
+	var booking struct {
+		UserID string `json:"userId"`
+	}
+	if err := json.Unmarshal(data, &booking); err != nil {
+		return "", nil
+	}
+	return booking.UserID, nil
+}
+
+// HandleBookingStream pushes a booking request's state transitions as
+// Server-Sent Events until the request reaches a terminal state. The
+// consumers publish every transition on bookingStatus:<request_id>. Only
+// the user who made the request, or an admin, can follow it.
+func HandleBookingStream(c *gin.Context, redisReq *redis.Client, requestID string) {
+	log.Println("HandleBookingStream called for request:", requestID)
+
+	if c.GetHeader("X-User-Role") != "admin" {
+		owner, err := requestOwner(c.Request.Context(), requestID)
+		if err != nil {
+			log.Println("Failed to look up request owner:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up request"})
+			return
+		}
+		if owner == "" || owner != c.GetHeader("X-User-Id") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), streamMaxDuration)
+	defer cancel()
+
+	// subscribe before reading the current state so no transition is missed
+	sub := redisReq.Subscribe(ctx, "bookingStatus:"+requestID)
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		log.Println("Failed to subscribe to booking status:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to subscribe to booking status"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	state, err := redisReq.Get(ctx, "bookingRequest:"+requestID).Result()
+	if err == nil {
+		c.SSEvent("status", gin.H{"request_id": requestID, "state": state})
+		c.Writer.Flush()
+		if terminalStates[state] {
+			return
+		}
+	}
+
+	updates := sub.Channel()
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+
+		case msg, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent("status", gin.H{"request_id": requestID, "state": msg.Payload})
+			return !terminalStates[msg.Payload]
+
+		case <-ping.C:
+			c.SSEvent("ping", "")
+			return true
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const maxSyncWait = 30 * time.Second
+
+var (
+	requestsRedis       *redis.Client
+	bookingsViewBaseURL string
+	bookingsViewClient  = &http.Client{Timeout: 5 * time.Second}
+)
+
+// preferredWait reads an RFC 7240 "Prefer: wait=<seconds>" header. Zero
+// means the client wants the usual 202 straight away.
+func preferredWait(c *gin.Context) time.Duration {
+	for _, pref := range strings.Split(c.GetHeader("Prefer"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pref), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "wait") {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || seconds <= 0 {
+			return 0
+		}
+
+		wait := time.Duration(seconds) * time.Second
+		if wait > maxSyncWait {
+			wait = maxSyncWait
+		}
+		return wait
+	}
+	return 0
+}
+
+// isOutcome tells whether a request has settled as far as a waiting client
+// is concerned. A hold is an outcome even though it can still be confirmed,
+// and so is joining the waitlist, which can last far longer than any wait.
+func isOutcome(state string) bool {
+	return terminalStates[state] || state == "held" || state == "waitlisted"
+}
+
+// subscribeOutcome starts listening on the request's status channel. It has
+// to happen before the request is published so no transition is missed.
+func subscribeOutcome(ctx context.Context, requestID string) (*redis.PubSub, error) {
+	sub := requestsRedis.Subscribe(ctx, "bookingStatus:"+requestID)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// waitForOutcome blocks until the request settles or ctx is done, in which
+// case it returns "".
+func waitForOutcome(ctx context.Context, sub *redis.PubSub, requestID string) string {
+	state, err := requestsRedis.Get(ctx, "bookingRequest:"+requestID).Result()
+	if err == nil && isOutcome(state) {
+		return state
+	}
+
+	updates := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ""
+
+		case msg, ok := <-updates:
+			if !ok {
+				return ""
+			}
+			if isOutcome(msg.Payload) {
+				return msg.Payload
+			}
+		}
+	}
+}
+
+// respondWithOutcome answers a waiting client with how its request ended,
+// including the booking (or order) for requests that got their seats. A
+// waitlisted request is still open, so it gets a 202 like a queued one.
+func respondWithOutcome(c *gin.Context, requestID, state string, isOrder bool) {
+	if state == "waitlisted" {
+		c.JSON(http.StatusAccepted, gin.H{"status": state, "request_id": requestID})
+		return
+	}
+
+	if state != "success" && state != "held" {
+		c.JSON(http.StatusConflict, gin.H{"status": state, "request_id": requestID})
+		return
+	}
+
+	path := "/api/v1/bookings/request/" + requestID
+	if isOrder {
+		path = "/api/v1/bookings/orders/" + requestID
+	}
+
+	booking, err := fetchBookingView(c.Request.Context(), path)
+	if err != nil {
+		// the outcome is known even if the row could not be read back
+		log.Printf("Failed to fetch booking for %s: %v", requestID, err)
+		c.JSON(http.StatusOK, gin.H{"status": state, "request_id": requestID})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": state, "request_id": requestID, "booking": booking})
+}
+
+func fetchBookingView(ctx context.Context, path string) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bookingsViewBaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bookingsViewClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bookings view returned %d", resp.StatusCode)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}